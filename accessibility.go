@@ -0,0 +1,142 @@
+package main
+
+import (
+	"image/color"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Constants for reading the accessibility settings
+const (
+	SPI_GETHIGHCONTRAST        = 0x0042
+	SPI_SETHIGHCONTRAST        = 0x0043
+	SPI_GETCLIENTAREAANIMATION = 0x1042 // "Show animations in Windows"
+	SPI_SETCLIENTAREAANIMATION = 0x1043
+	HCF_HIGHCONTRASTON         = 0x00000001
+	transparencyValue          = "EnableTransparency" // Under personalizeKey, 0 when transparency effects are off
+)
+
+// highContrast mirrors the Windows HIGHCONTRASTW structure
+type highContrast struct {
+	cbSize            uint32
+	dwFlags           uint32
+	lpszDefaultScheme *uint16
+}
+
+// Overlay colors for high contrast: solid black and white with a bright highlight
+var contrastPalette = overlayPalette{
+	panel:        color.NRGBA{0, 0, 0, 255},
+	track:        color.NRGBA{255, 255, 255, 255},
+	handle:       color.NRGBA{255, 255, 0, 255},
+	button:       color.NRGBA{0, 0, 0, 255},
+	buttonChosen: color.NRGBA{0, 0, 160, 255},
+	flake:        "#ffffff",
+	backdropTint: "#000000",
+}
+
+// HighContrast reports whether a high contrast theme is on
+func HighContrast() bool {
+	hc := highContrast{}
+	hc.cbSize = uint32(unsafe.Sizeof(hc))
+	ok, _, _ := procSystemParametersInfo.Call(SPI_GETHIGHCONTRAST, uintptr(hc.cbSize), uintptr(unsafe.Pointer(&hc)), 0)
+	return ok != 0 && hc.dwFlags&HCF_HIGHCONTRASTON != 0
+}
+
+// TransparencyEnabled reports whether Windows transparency effects are on
+func TransparencyEnabled() bool {
+	k, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.QUERY_VALUE)
+	if err != nil {
+		return true
+	}
+	defer k.Close()
+
+	enabled, _, err := k.GetIntegerValue(transparencyValue)
+	return err != nil || enabled != 0
+}
+
+// AnimationsEnabled reports whether "Show animations in Windows" is on
+func AnimationsEnabled() bool {
+	var enabled int32
+	if ok, _, _ := procSystemParametersInfo.Call(SPI_GETCLIENTAREAANIMATION, 0, uintptr(unsafe.Pointer(&enabled)), 0); ok == 0 {
+		return true
+	}
+	return enabled != 0
+}
+
+// WatchAccessibility tells the game when high contrast, transparency
+// effects or animations are switched. Call it from the message window's
+// setup function.
+func WatchAccessibility(w *MessageWindow, game *Game) {
+	update := func() {
+		contrast, transparency, animations := HighContrast(), TransparencyEnabled(), AnimationsEnabled()
+		game.Post(func(g *Game) {
+			g.SetAccessibility(contrast, transparency)
+			g.SetReducedMotion(!animations)
+		})
+	}
+
+	// Transparency effects are announced as a named setting change rather
+	// than with a code of their own, so check again on any of those
+	w.Handle(WM_SETTINGCHANGE, func(wParam, lParam uintptr) uintptr {
+		if wParam == SPI_SETHIGHCONTRAST || wParam == SPI_SETCLIENTAREAANIMATION || lParam != 0 {
+			update()
+		}
+		return 0
+	})
+	update()
+}
+
+// SetReducedMotion records whether Windows animations are turned off
+func (g *Game) SetReducedMotion(reduced bool) {
+	g.reducedMotion = reduced
+	g.applyMotion()
+}
+
+// applyMotion holds the snow still on its current frame while Windows
+// animations are off, unless always_animate asks for motion anyway
+func (g *Game) applyMotion() {
+	if g.reducedMotion && !g.config.AlwaysAnimate {
+		g.Pause(pausedByReducedMotion)
+	} else {
+		g.Resume(pausedByReducedMotion)
+	}
+}
+
+// SetAccessibility switches to solid, opaque drawing while high contrast
+// is on or transparency effects are off
+func (g *Game) SetAccessibility(contrast, transparency bool) {
+	if contrast == g.highContrast && !transparency == g.solid {
+		return
+	}
+	g.highContrast = contrast
+	g.solid = contrast || !transparency
+	g.updateColor()
+	ApplyBackdrop(FindGameWindow(), g.displayConfig(g.config))
+}
+
+// displayConfig returns the settings as they should be drawn, with the
+// colors adjusted for the Windows theme and accessibility settings
+func (g *Game) displayConfig(cfg Config) Config {
+	if e, ok := effects[cfg.Effect]; ok {
+		if e.color != "" && cfg.Color == DefaultConfig().Color {
+			cfg.Color = e.color
+		}
+		cfg.Opacity *= e.opacity
+	}
+	cfg = cfg.Themed(g.lightTheme)
+	if cfg.DayNight {
+		tint := cfg.dayTint(time.Now())
+		cfg.Color = tintColor(cfg.Color, tint)
+		cfg.BackdropTint = tintColor(cfg.BackdropTint, tint)
+	}
+	if g.solid {
+		cfg.Opacity = 1
+		cfg.Backdrop = backdropNone
+	}
+	if g.highContrast {
+		cfg.Color = contrastPalette.flake
+	}
+	return cfg
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	_ "embed"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Share of the screen height the aurora hangs down from the top
+const auroraHeight = 0.45
+
+// Kage shader drawing waving curtains of green and purple light
+//
+//go:embed assets/aurora.kage
+var auroraKage []byte
+
+// The compiled aurora shader, made on first use, and whether it wouldn't
+// compile on this GPU. The aurora setting is left alone so it's still
+// saved and shown as the user chose.
+var (
+	auroraShader *ebiten.Shader
+	auroraFailed bool
+)
+
+// drawAurora draws the northern lights along the top of the screen,
+// behind the snow, if they are turned on
+func (g *Game) drawAurora(screen *ebiten.Image) {
+	if !g.config.Aurora || auroraFailed {
+		return
+	}
+	if auroraShader == nil {
+		shader, err := ebiten.NewShader(auroraKage)
+		if err != nil {
+			log.Println("Could not compile the aurora shader:", err)
+			auroraFailed = true
+			return
+		}
+		auroraShader = shader
+	}
+
+	w, h := g.screenWidth, int(float64(g.screenHeight)*auroraHeight)
+	op := &ebiten.DrawRectShaderOptions{
+		Uniforms: map[string]any{
+			"Time":       float32(g.auroraTime),
+			"Size":       []float32{float32(w), float32(h)},
+			"Brightness": float32(g.config.AuroraBrightness),
+		},
+	}
+	screen.DrawRectShader(w, h, auroraShader, op)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry key and value that make Windows start winsnow at login
+const (
+	runKey   = `Software\Microsoft\Windows\CurrentVersion\Run`
+	runValue = "winsnow"
+)
+
+// SetAutostart registers or deregisters winsnow to start when the user logs
+// in, either directly or through the supervisor that restarts it if it crashes
+func SetAutostart(enabled, service bool) error {
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, runKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	if !enabled {
+		if err := k.DeleteValue(runValue); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	command, err := autostartCommand(service)
+	if err != nil {
+		return err
+	}
+	return k.SetStringValue(runValue, command)
+}
+
+// autostartCommand returns the command line that starts this copy of winsnow,
+// with the path quoted in case it contains spaces
+func autostartCommand(service bool) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	command := windows.EscapeArg(exe) + " run"
+	if service {
+		command = windows.EscapeArg(exe) + " service"
+	}
+	if portable {
+		command += " -portable"
+	}
+	return command, nil
+}
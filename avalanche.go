@@ -0,0 +1,93 @@
+package main
+
+import "math"
+
+// When and how a deep drift on a window gives way
+const (
+	avalancheDepth  = 0.9  // Share of pile_max_depth a drift must reach before it gives way
+	avalancheFrames = 24   // Frames the collapse lasts
+	avalancheWidth  = 10   // Columns either side of the deepest that come down with it
+	avalancheShare  = 0.15 // Share of the sliding snow that breaks away each frame
+)
+
+// avalanche is a drift collapsing off the edge it lies on
+type avalanche struct {
+	from, to int     // Columns that are sliding
+	frames   int     // Frames left in the collapse, 0 if none is under way
+	dir      float64 // Which way the snow tumbles, toward the nearer end of the edge
+}
+
+// avalanches starts a collapse on any window whose drift has grown too
+// deep, and carries on the ones under way
+func (g *Game) avalanches() {
+	if !g.config.Avalanches {
+		return
+	}
+	for _, p := range g.piles {
+		if p.kind != surfaceWindow {
+			continue
+		}
+		if p.slide.frames == 0 {
+			p.slide = p.startAvalanche(g.config.PileMaxDepth * avalancheDepth)
+		}
+		if p.slide.frames > 0 {
+			g.tumble(p)
+		}
+	}
+}
+
+// startAvalanche finds the deepest column and, if it is deeper than
+// threshold, returns the collapse around it
+func (p *Pile) startAvalanche(threshold float64) avalanche {
+	deepest := 0
+	for i, d := range p.depth {
+		if d > p.depth[deepest] {
+			deepest = i
+		}
+	}
+	if threshold <= 0 || p.depth[deepest] < threshold {
+		return avalanche{}
+	}
+
+	dir := 1.0
+	if deepest < len(p.depth)/2 {
+		dir = -1
+	}
+	return avalanche{
+		from:   max(deepest-avalancheWidth, 0),
+		to:     min(deepest+avalancheWidth, len(p.depth)-1),
+		frames: avalancheFrames,
+		dir:    dir,
+	}
+}
+
+// tumble takes a frame's worth of snow off the collapsing drift and
+// throws it off the edge in a burst of clumps
+func (g *Game) tumble(p *Pile) {
+	s := &p.slide
+	s.frames--
+	for i := s.from; i <= s.to; i += clumpColumns {
+		taken := 0.0
+		for j := i; j <= min(i+clumpColumns-1, s.to); j++ {
+			take := p.depth[j] * avalancheShare
+			if s.frames == 0 {
+				take = p.depth[j] // Whatever is left comes down at the end
+			}
+			p.depth[j] -= take
+			taken += take
+		}
+
+		// Turn the depth taken back into a flake of the same amount of snow
+		size := min(math.Sqrt(taken*pileColumnWidth/pileDeposit), clumpMaxSize)
+		if size < 1 {
+			continue
+		}
+		g.emitDebris(Snowflake{
+			x:     p.x + float64(i*pileColumnWidth),
+			y:     p.y + 1, // Down the face of the window, past the pile it came from
+			size:  size,
+			speed: g.config.SpeedMax,
+			vx:    s.dir * (1 + 2*g.rng.Float64()),
+		})
+	}
+}
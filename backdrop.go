@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Backdrops that can be drawn behind the snow
+const (
+	backdropNone    = "none"    // Flat black fill
+	backdropBlur    = "blur"    // Blur of whatever is behind the window
+	backdropAcrylic = "acrylic" // Frosted glass tinted with backdrop_tint
+)
+
+// Constants for the undocumented window composition API, which Windows
+// itself uses for the blurred taskbar and Start menu
+const (
+	WCA_ACCENT_POLICY               = 19
+	ACCENT_DISABLED                 = 0
+	ACCENT_ENABLE_BLURBEHIND        = 3
+	ACCENT_ENABLE_ACRYLICBLURBEHIND = 4
+	ACCENT_FLAG_DRAW_ALL_BORDERS    = 0x1E0
+	acrylicTintAlpha                = 0x40
+)
+
+// accentPolicy mirrors the undocumented ACCENT_POLICY structure
+type accentPolicy struct {
+	accentState   uint32
+	accentFlags   uint32
+	gradientColor uint32 // AABBGGRR
+	animationID   uint32
+}
+
+// windowCompositionAttribData mirrors the undocumented WINDOWCOMPOSITIONATTRIBDATA structure
+type windowCompositionAttribData struct {
+	attrib uint32
+	data   unsafe.Pointer
+	size   uintptr
+}
+
+// validateBackdrop checks the backdrop setting
+func validateBackdrop(backdrop string) error {
+	switch backdrop {
+	case backdropNone, backdropBlur, backdropAcrylic:
+		return nil
+	}
+	return fmt.Errorf("backdrop must be %s, %s or %s, got %q", backdropNone, backdropBlur, backdropAcrylic, backdrop)
+}
+
+// ApplyBackdrop turns the blurred backdrop behind the window on or off.
+// Windows only blurs behind top-level windows, so it has no effect once the
+// window is attached to the wallpaper.
+func ApplyBackdrop(hwnd uintptr, cfg Config) {
+	if hwnd == 0 {
+		return
+	}
+
+	accent := accentPolicy{accentState: ACCENT_DISABLED}
+	switch cfg.Backdrop {
+	case backdropBlur:
+		accent = accentPolicy{accentState: ACCENT_ENABLE_BLURBEHIND, accentFlags: ACCENT_FLAG_DRAW_ALL_BORDERS}
+	case backdropAcrylic:
+		tint, _ := ParseColor(cfg.BackdropTint)
+		accent = accentPolicy{
+			accentState:   ACCENT_ENABLE_ACRYLICBLURBEHIND,
+			accentFlags:   ACCENT_FLAG_DRAW_ALL_BORDERS,
+			gradientColor: acrylicTintAlpha<<24 | uint32(tint.B)<<16 | uint32(tint.G)<<8 | uint32(tint.R),
+		}
+	}
+
+	data := windowCompositionAttribData{
+		attrib: WCA_ACCENT_POLICY,
+		data:   unsafe.Pointer(&accent),
+		size:   unsafe.Sizeof(accent),
+	}
+	procSetWindowCompositionAttribute.Call(hwnd, uintptr(unsafe.Pointer(&data)))
+}
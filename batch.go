@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image/color"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Most quads sent in one DrawTriangles call, so their indices fit in a uint16
+const batchQuads = (1<<16 - 1) / 4
+
+// Batch gathers flakes drawn with the same texture and draws each texture's
+// flakes in one DrawTriangles call, so thousands of flakes take a handful of
+// draw calls rather than one each
+type Batch struct {
+	target  *ebiten.Image
+	order   []*ebiten.Image // Textures in the order they were first used
+	batches map[*ebiten.Image]*triangles
+}
+
+// triangles are the quads waiting to be drawn with one texture
+type triangles struct {
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+// Add queues the texture to be drawn onto target, placed by m and tinted
+// with c. Switching to another target draws what was queued for the last.
+func (b *Batch) Add(target, texture *ebiten.Image, m ebiten.GeoM, c color.NRGBA) {
+	if target != b.target {
+		b.Flush()
+		b.target = target
+	}
+	if b.batches == nil {
+		b.batches = map[*ebiten.Image]*triangles{}
+	}
+	t, ok := b.batches[texture]
+	if !ok {
+		t = &triangles{}
+		b.batches[texture] = t
+	}
+	if len(t.vertices)/4 >= batchQuads {
+		b.draw(texture, t)
+	}
+	if !slices.Contains(b.order, texture) {
+		b.order = append(b.order, texture)
+	}
+
+	bounds := texture.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	r, g, bl, a := float32(c.R)/255, float32(c.G)/255, float32(c.B)/255, float32(c.A)/255
+	base := uint16(len(t.vertices))
+	for _, corner := range [4][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		x, y := m.Apply(corner[0]*w, corner[1]*h)
+		t.vertices = append(t.vertices, ebiten.Vertex{
+			DstX:   float32(x),
+			DstY:   float32(y),
+			SrcX:   float32(bounds.Min.X) + float32(corner[0]*w),
+			SrcY:   float32(bounds.Min.Y) + float32(corner[1]*h),
+			ColorR: r,
+			ColorG: g,
+			ColorB: bl,
+			ColorA: a,
+		})
+	}
+	t.indices = append(t.indices, base, base+1, base+2, base+1, base+3, base+2)
+}
+
+// Flush draws everything queued
+func (b *Batch) Flush() {
+	for _, texture := range b.order {
+		b.draw(texture, b.batches[texture])
+	}
+	b.order = b.order[:0]
+}
+
+// draw draws the quads queued for a texture and empties the queue, keeping
+// its memory for the next frame
+func (b *Batch) draw(texture *ebiten.Image, t *triangles) {
+	if len(t.vertices) > 0 {
+		op := &ebiten.DrawTrianglesOptions{Filter: ebiten.FilterLinear}
+		b.target.DrawTriangles(t.vertices, t.indices, texture, op)
+	}
+	t.vertices = t.vertices[:0]
+	t.indices = t.indices[:0]
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Strength of a blizzard at its peak
+const (
+	blizzardFlakes = 4.0  // Times as many flakes
+	blizzardSpeed  = 2.5  // Times as fast
+	blizzardWind   = 8.0  // Extra wind, all blowing one way
+	blizzardRamp   = 30.0 // Seconds to build up to full strength and to calm down again
+	blizzardStreak = 3.0  // Length of the streaks behind flakes, in frames of movement
+)
+
+// Blizzard is a storm that builds up, rages for a while and calms down,
+// on top of the configured weather
+type Blizzard struct {
+	level float64   // How strong the storm is, from 0 for none to 1 at its peak
+	until time.Time // When the storm starts calming down
+	next  time.Time // When the next random storm blows in, zero if none is due
+	dir   float64   // Which way the storm blows, 1 for right or -1 for left
+	raged bool      // Whether the storm has reached the screen, to announce it clearing
+}
+
+// StartBlizzard whips up a storm now, or makes the current one last longer
+func (g *Game) StartBlizzard() {
+	g.blowBlizzard(time.Now().Add(minutes(g.config.BlizzardMinutes)))
+}
+
+// blowBlizzard starts a storm, or keeps the current one going, until the given time
+func (g *Game) blowBlizzard(until time.Time) {
+	b := &g.blizzard
+	if b.level == 0 {
+		b.dir = 1
+		if g.rng.Intn(2) == 0 {
+			b.dir = -1
+		}
+		g.notify("Blizzard warning", "A blizzard is blowing in.")
+	}
+	b.until = until
+	b.raged = true
+}
+
+// updateBlizzard starts random storms when they are due and moves the
+// strength of the storm a step toward where it should be
+func (g *Game) updateBlizzard(r *rand.Rand) {
+	b := &g.blizzard
+	now := time.Now()
+
+	switch {
+	case !g.config.BlizzardCycle:
+		b.next = time.Time{}
+	case b.next.IsZero():
+		// Storms come at random, on average once per blizzard_every minutes
+		wait := r.ExpFloat64() * g.config.BlizzardEvery * float64(time.Minute)
+		b.next = now.Add(time.Duration(wait))
+	case now.After(b.next):
+		b.next = time.Time{}
+		g.StartBlizzard()
+	}
+
+	target := 0.0
+	if now.Before(b.until) {
+		target = 1
+	}
+	if b.level == target {
+		return
+	}
+
+	step := 1 / (blizzardRamp * float64(g.stepRate()))
+	if target > b.level {
+		b.level = min(b.level+step, target)
+	} else {
+		b.level = max(b.level-step, target)
+	}
+	if b.level == 0 && b.raged {
+		b.raged = false
+		g.notify("The blizzard has passed", "The snow is settling down again.")
+	}
+	g.resizeFlakes()
+}
+
+// blizzardFlakeScale returns how many times the configured flakes the storm brings
+func (g *Game) blizzardFlakeScale() float64 {
+	return 1 + g.blizzard.level*(blizzardFlakes-1)
+}
+
+// blizzardSpeedScale returns how many times faster the storm makes flakes fall
+func (g *Game) blizzardSpeedScale() float64 {
+	return 1 + g.blizzard.level*(blizzardSpeed-1)
+}
+
+// baseWind returns the wind across the whole screen, with the storm's on top
+func (g *Game) baseWind() float64 {
+	return g.wind + g.blizzard.level*blizzardWind*g.blizzard.dir
+}
+
+// drawStreak draws a faint line behind a flake racing along in a blizzard
+func (g *Game) drawStreak(screen *ebiten.Image, f Snowflake, alpha float64) {
+	level := g.blizzard.level
+	if level == 0 {
+		return
+	}
+	dx := f.vx * blizzardStreak * level
+	dy := f.vy * blizzardStreak * level
+	if math.Abs(dx) < 1 {
+		return
+	}
+
+	c := g.flakeColor
+	c.A = uint8(float64(c.A) * alpha * level / 2)
+	vector.StrokeLine(screen, float32(f.x-dx), float32(f.y-dy), float32(f.x), float32(f.y), float32(max(f.size/2, 1)), c, true)
+}
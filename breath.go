@@ -0,0 +1,69 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How the mist breathed out beside a still mouse pointer drifts and fades
+const (
+	breathIdle    = 90     // Frames the pointer must be still before it starts breathing
+	breathEvery   = 180    // Frames from one breath to the next
+	breathPuffs   = 6      // Puffs of mist in each breath
+	breathLife    = 100    // Most frames a puff lasts
+	breathSize    = 5.0    // Radius of a fresh puff in pixels
+	breathGrow    = 0.25   // Pixels a puff spreads each frame
+	breathSpeed   = 1.2    // Speed a puff leaves the pointer at in pixels per frame
+	breathDamp    = 0.95   // Share of its speed a puff keeps from one frame to the next
+	breathRise    = 0.0025 // Lift on the warm breath in pixels per frame per frame
+	breathWind    = 0.3    // Share of the wind that carries the mist along
+	breathOpacity = 0.3    // Opacity of a fresh puff
+)
+
+// Color of the mist
+var breathColor = color.NRGBA{fogGrey, fogGrey, fogBlue, 0xff}
+
+// Puffs of breath slow, rise and spread as they fade, over the snow
+var breathBehavior = &Behavior{gravity: -breathRise, drag: breathDamp, wind: breathWind, grow: breathGrow, front: true, draw: drawBreath}
+
+// updateBreath breathes out a little mist beside the pointer every so often
+// while it is still
+func (g *Game) updateBreath(r *rand.Rand) {
+	c := &g.cursor
+	if !g.config.CursorBreath || !c.known || c.still < breathIdle || (c.still-breathIdle)%breathEvery != 0 {
+		return
+	}
+	// Out from the right of the arrow's point, as if it were breathing
+	for range breathPuffs {
+		life := breathLife/2 + r.Intn(breathLife/2)
+		g.particles.Emit(Particle{
+			x:      c.x + cursorWidth + r.Float64()*3,
+			y:      c.y + r.Float64()*3,
+			vx:     breathSpeed * (0.5 + r.Float64()*0.5),
+			vy:     -breathSpeed * r.Float64() * 0.4,
+			size:   breathSize * (0.6 + r.Float64()*0.4),
+			life:   life,
+			span:   life,
+			color:  breathColor,
+			behave: breathBehavior,
+		})
+	}
+}
+
+// drawBreath draws a puff as a soft round glow fading as it spreads
+func drawBreath(g *Game, screen *ebiten.Image, p Particle) {
+	if glowSprite == nil {
+		glowSprite = makeGlow()
+	}
+	scale := p.size * 2 / spriteCell
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-spriteCell/2, -spriteCell/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(p.x, p.y)
+	op.ColorScale.ScaleWithColor(p.color)
+	op.ColorScale.ScaleAlpha(float32(breathOpacity * (1 - p.age())))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(glowSprite, op)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// How flakes stick together and break apart in the air
+const (
+	clumpEvery     = 6    // Frames between checks for flakes touching
+	clumpGrowth    = 2.0  // Largest clump as a multiple of size_max
+	clumpSplitWind = 3.0  // Wind strength that starts breaking clumps apart
+	clumpSplit     = 0.02 // Chance per check for each unit of wind above that of a clump breaking
+)
+
+// clumpFlakes sticks flakes that touch together into bigger clumps, and
+// lets strong wind break clumps apart again. Flakes are bucketed by
+// position first so only neighbours need comparing.
+func (g *Game) clumpFlakes(r *rand.Rand) {
+	if g.config.Clumping == 0 || g.effect().move != nil {
+		return
+	}
+	g.clumpTick++
+	if g.clumpTick%clumpEvery != 0 {
+		return
+	}
+
+	g.index()
+
+	largest := g.config.SizeMax * clumpGrowth
+	for i := range g.snowflakes {
+		a := &g.snowflakes[i]
+		if a.y <= 0 {
+			continue
+		}
+		if fragment, ok := g.splitClump(a, r); ok {
+			g.emitDebris(fragment)
+		}
+
+		g.grid.Near(a.x, a.y, a.size/2+largest/2, func(j int) {
+			b := &g.snowflakes[j]
+			if j <= i || b.y <= 0 || math.Hypot(a.x-b.x, a.y-b.y) > (a.size+b.size)/2 {
+				return
+			}
+			if r.Float64() >= g.config.Clumping || math.Cbrt(a.size*a.size*a.size+b.size*b.size*b.size) > largest {
+				return
+			}
+			merge(a, b)
+			g.respawn(b, r)
+		})
+	}
+}
+
+// merge sticks b onto a, keeping the snow's volume and momentum
+func merge(a, b *Snowflake) {
+	va, vb := a.size*a.size*a.size, b.size*b.size*b.size
+	total := va + vb
+	a.vx = (a.vx*va + b.vx*vb) / total
+	a.vy = (a.vy*va + b.vy*vb) / total
+	a.speed = max(a.speed, b.speed)
+	a.size = math.Cbrt(total)
+	a.clumped = true
+}
+
+// splitClump breaks a bit off a clump caught in strong wind, returning it
+// as a fragment that melts away once it lands
+func (g *Game) splitClump(f *Snowflake, r *rand.Rand) (Snowflake, bool) {
+	wind := math.Abs(g.baseWind())
+	if !f.clumped || wind < clumpSplitWind || r.Float64() >= (wind-clumpSplitWind)*clumpSplit {
+		return Snowflake{}, false
+	}
+
+	f.size = math.Cbrt(f.size * f.size * f.size / 2)
+	if f.size <= g.config.SizeMax {
+		f.clumped = false
+	}
+	return Snowflake{
+		x:     f.x + f.size,
+		y:     f.y,
+		size:  f.size,
+		speed: f.speed,
+		vx:    f.vx * 1.2,
+		vy:    f.vy,
+	}, true
+}
+
+// respawn sends a flake back to the top of the screen, giving a clump
+// back its own size first
+func (g *Game) respawn(f *Snowflake, r *rand.Rand) {
+	w, _ := g.fallSize()
+	f.y = 0
+	f.x = r.Float64() * w
+	if f.clumped {
+		f.size = g.config.flakeSize(r)
+		f.speed = g.config.terminalSpeed(f.size, r)
+		f.clumped = false
+	}
+	f.vy = f.speed
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RunCommand carries out a command sent by another invocation and returns the reply
+func (g *Game) RunCommand(cmd string) string {
+	switch cmd {
+	case "pause":
+		g.Pause(pausedByUser)
+		return "paused"
+	case "resume":
+		g.Resume(pausedByUser)
+		return "resumed"
+	case "status":
+		return g.Status()
+	case "blizzard":
+		g.StartBlizzard()
+		return "blizzard started"
+	case "fireworks":
+		g.StartFireworks()
+		return "fireworks started"
+	case "confetti":
+		g.FireConfetti()
+		return "confetti fired"
+	case "sleigh":
+		g.FlySleigh()
+		return "sleigh on its way"
+	case "plow":
+		g.SendPlow()
+		return "plow on its way"
+	case "quit":
+		g.quit = true
+		return "quitting"
+	default:
+		return fmt.Sprintf("error: unknown command %q", cmd)
+	}
+}
+
+// Status describes the current state of the simulation in one line
+func (g *Game) Status() string {
+	state := "running"
+	if g.paused != 0 {
+		state = "paused"
+	}
+	preset := g.config.Preset
+	if preset == "" {
+		preset = "custom"
+	}
+	return fmt.Sprintf("%s, %d flakes, preset %s, wind %.2f, %.0f fps%s",
+		state, len(g.snowflakes), preset, g.wind, ebiten.ActualFPS(), g.weatherStatus())
+}
+
+// checkConfig validates the config file and overrides and prints the
+// settings winsnow would run with. It returns the process exit code.
+func checkConfig(configPath string, flags *Flags) int {
+	source := "not found, using defaults"
+	if _, err := os.Stat(configPath); err == nil {
+		source = "found"
+	}
+	fmt.Printf("# Config file: %s (%s)\n", configPath, source)
+	if portable {
+		fmt.Println("# Portable mode: the registry is not used")
+	} else if HasRegistrySettings() {
+		fmt.Printf("# Registry settings: HKEY_CURRENT_USER\\%s\n", registryKey)
+	}
+
+	// A dry run, so an old file is upgraded only in memory
+	cfg, err := ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if err := cfg.ApplyOverrides(flags); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	fmt.Println("# Effective settings after environment and command-line overrides:")
+	if err := toml.NewEncoder(os.Stdout).Encode(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// runConfigCommand handles "winsnow config export|import [file]".
+// Export writes the saved settings as JSON to the file or stdout;
+// import reads them back from the file or stdin and saves them.
+func runConfigCommand(args []string, configPath string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: winsnow config export|import [file]")
+	}
+
+	switch args[0] {
+	case "export":
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		out := io.Writer(os.Stdout)
+		if len(args) == 2 {
+			f, err := os.Create(args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+
+	case "import":
+		in := io.Reader(os.Stdin)
+		if len(args) == 2 {
+			f, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		// Anything missing from the file falls back to the defaults
+		cfg := DefaultConfig()
+		if err := json.NewDecoder(in).Decode(&cfg); err != nil {
+			return fmt.Errorf("reading settings: %w", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid settings: %w", err)
+		}
+		if cfg.Preset != "" {
+			if _, ok := cfg.LookupPreset(cfg.Preset); !ok {
+				return fmt.Errorf("invalid settings: unknown preset %q", cfg.Preset)
+			}
+		}
+		return SaveSettings(configPath, cfg)
+
+	default:
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How confetti looks and behaves
+const (
+	confettiSpeed   = 0.3  // Times as fast as snow confetti falls
+	confettiSway    = 3.0  // Times as far as snow confetti rocks from side to side
+	confettiScale   = 3.0  // Confetti is drawn this many times the flake size
+	confettiFlutter = 3.0  // Times a piece turns edge-on per sway
+	cannonPieces    = 150  // Pieces fired from each side by the cannon
+	cannonSpeedMin  = 10.0 // Slowest a piece leaves the cannon, in pixels per frame
+	cannonSpeedMax  = 22.0 // Fastest a piece leaves the cannon, in pixels per frame
+	cannonGravity   = 0.25 // Pull on a fired piece in pixels per frame per frame
+	cannonDrag      = 0.96 // Share of its speed a fired piece keeps from one frame to the next
+	cannonFall      = 1.5  // Fastest fired confetti drifts down once it has slowed
+	cannonFlap      = 0.08 // Radians a fired piece moves through its sway each frame
+	cannonLife      = 3600 // Most frames a fired piece lasts, long enough to reach the ground
+)
+
+// Bright colors the confetti is picked from
+var confettiPalette = []color.NRGBA{
+	{0xe6, 0x39, 0x46, 0xff}, // Red
+	{0xf4, 0xa2, 0x61, 0xff}, // Orange
+	{0xf9, 0xd7, 0x1c, 0xff}, // Yellow
+	{0x2a, 0x9d, 0x8f, 0xff}, // Teal
+	{0x45, 0x7b, 0xe8, 0xff}, // Blue
+	{0x9b, 0x5d, 0xe5, 0xff}, // Purple
+	{0xff, 0x70, 0xa6, 0xff}, // Pink
+}
+
+// The confetti shape, a white rectangle on transparent, made on first use
+var confettiSprite *ebiten.Image
+
+// makeConfetti draws a small paper rectangle twice as long as it is wide
+func makeConfetti() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, spriteCell, spriteCell))
+	for py := spriteCell/2 - 5; py < spriteCell/2+5; py++ {
+		for px := spriteCell/2 - 10; px < spriteCell/2+10; px++ {
+			img.SetNRGBA(px, py, color.NRGBA{255, 255, 255, 255})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// drawConfetti draws a piece of confetti in its own color, tumbling and
+// flashing as it turns edge-on and back
+func drawConfetti(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	if confettiSprite == nil {
+		confettiSprite = makeConfetti()
+	}
+	tint := confettiPalette[f.design%len(confettiPalette)]
+	flutter := math.Cos(f.phase * confettiFlutter)
+	drawTumbling(screen, confettiSprite, f, f.size*confettiScale/spriteCell, flutter, color.NRGBA{tint.R, tint.G, tint.B, c.A})
+}
+
+// Pieces fired from the cannon shoot up, slow down and flutter back to the
+// ground over the snow
+var cannonBehavior = &Behavior{gravity: cannonGravity, drag: cannonDrag, front: true, draw: drawPiece, move: flutter}
+
+// FireConfetti fires a burst of confetti up from both bottom corners of
+// the screen, over whatever is falling
+func (g *Game) FireConfetti() {
+	r := g.rng
+	// Each side fires toward the other, the right corner leftwards
+	for _, side := range []float64{-1, 1} {
+		x := 0.0
+		if side < 0 {
+			x = float64(g.screenWidth)
+		}
+		for range cannonPieces {
+			// Up and in toward the middle of the screen, spread around 60 degrees
+			angle := math.Pi/3 + (r.Float64()*2-1)*math.Pi/10
+			speed := cannonSpeedMin + r.Float64()*(cannonSpeedMax-cannonSpeedMin)
+			g.particles.Emit(Particle{
+				x:      x,
+				y:      float64(g.screenHeight),
+				vx:     side * math.Cos(angle) * speed,
+				vy:     -math.Sin(angle) * speed,
+				size:   g.config.SizeMin + r.Float64()*(g.config.SizeMax-g.config.SizeMin),
+				angle:  r.Float64() * 2 * math.Pi,
+				phase:  r.Float64() * 2 * math.Pi,
+				life:   cannonLife,
+				span:   cannonLife,
+				color:  confettiPalette[r.Intn(len(confettiPalette))],
+				behave: cannonBehavior,
+			})
+		}
+	}
+}
+
+// flutter lets a fired piece drift down no faster than paper would, rocking
+// and turning as it goes, until it falls off the bottom of the screen
+func flutter(g *Game, p *Particle) {
+	p.vy = min(p.vy, cannonFall)
+	p.phase += cannonFlap
+	p.x += math.Sin(p.phase) * 0.8
+	p.angle += spriteSpin / max(p.size, 1) * (1 + math.Abs(p.vx))
+	if p.y > float64(g.screenHeight) {
+		p.life = 0
+	}
+}
+
+// drawPiece draws a piece fired from the cannon, tumbling like the falling confetti
+func drawPiece(g *Game, screen *ebiten.Image, p Particle) {
+	if confettiSprite == nil {
+		confettiSprite = makeConfetti()
+	}
+	f := Snowflake{x: p.x, y: p.y, angle: p.angle}
+	drawTumbling(screen, confettiSprite, f, p.size*confettiScale/spriteCell, math.Cos(p.phase*confettiFlutter), p.color)
+}
@@ -0,0 +1,662 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds all the tunable parameters of the snow effect
+type Config struct {
+	Version int `toml:"version" json:"version"` // Layout version of the config file
+
+	Flakes        int     `toml:"flakes" json:"flakes"`                   // Number of snowflakes on screen, or on a 1920x1080 screen while density is set
+	Density       float64 `toml:"density" json:"density"`                 // Snowflakes per megapixel of screen, used instead of flakes so large screens aren't sparse; 0 for a fixed count
+	SpeedMin      float64 `toml:"speed_min" json:"speed_min"`             // Slowest fall speed in pixels per frame
+	SpeedMax      float64 `toml:"speed_max" json:"speed_max"`             // Fastest fall speed in pixels per frame
+	SizeMin       float64 `toml:"size_min" json:"size_min"`               // Smallest flake diameter in pixels
+	SizeMax       float64 `toml:"size_max" json:"size_max"`               // Largest flake diameter in pixels
+	Wind          float64 `toml:"wind" json:"wind"`                       // Maximum wind strength in either direction
+	WindBias      float64 `toml:"wind_bias" json:"wind_bias"`             // Average wind, negative blows left
+	GravityX      float64 `toml:"gravity_x" json:"gravity_x"`             // Sideways pull of gravity, which with gravity_y sets the way the snow falls; negative pulls left
+	GravityY      float64 `toml:"gravity_y" json:"gravity_y"`             // Downward pull of gravity, negative for snow that falls up the screen
+	WindChangeMin float64 `toml:"wind_change_min" json:"wind_change_min"` // Minimum frames between wind changes
+	WindChangeMax float64 `toml:"wind_change_max" json:"wind_change_max"` // Maximum frames between wind changes
+	Gusts         float64 `toml:"gusts" json:"gusts"`                     // Strength of gusts rippling through the snow relative to the wind, 0 for even wind
+	GustSize      float64 `toml:"gust_size" json:"gust_size"`             // Rough width of a gust in pixels
+	Wobble        float64 `toml:"wobble" json:"wobble"`                   // How far flakes sway from side to side as they fall, 0 for straight lines
+	Turbulence    float64 `toml:"turbulence" json:"turbulence"`           // How much flakes are knocked about by small eddies
+	Color         string  `toml:"color" json:"color"`                     // Flake color as #rrggbb
+	Palette       string  `toml:"palette" json:"palette"`                 // Colors flakes are picked from: classic, golden, pastel, neon or candy-cane
+	Opacity       float64 `toml:"opacity" json:"opacity"`                 // Flake opacity from 0 to 1
+	FlakeStyle    string  `toml:"flake_style" json:"flake_style"`         // How flakes are drawn: crystal, sprite, dot, custom, glyph or shader
+	Glow          float64 `toml:"glow" json:"glow"`                       // Strength of the halo around flakes drawn with the shader flake style, from 0 to 1
+	Effect        string  `toml:"effect" json:"effect"`                   // What falls: snow, rain, sleet, hail, leaves, petals, fireflies or confetti
+	Backdrop      string  `toml:"backdrop" json:"backdrop"`               // Behind the snow: none, blur or acrylic
+	BackdropTint  string  `toml:"backdrop_tint" json:"backdrop_tint"`     // Tint of the acrylic backdrop as #rrggbb
+	FollowTheme   bool    `toml:"follow_theme" json:"follow_theme"`       // Pick default colors to suit the Windows light or dark theme
+	Monitor       int     `toml:"monitor" json:"monitor"`                 // Index of the monitor to show snow on, 0 for the primary
+
+	SizeDist   string  `toml:"size_distribution" json:"size_distribution"` // How sizes are spread between size_min and size_max: uniform, normal or heavy
+	SizeSpread float64 `toml:"size_spread" json:"size_spread"`             // Standard deviation of the normal distribution in pixels
+	SizeTail   float64 `toml:"size_tail" json:"size_tail"`                 // Exponent of the heavy distribution; higher makes big flakes rarer
+
+	WindBack      float64 `toml:"wind_back" json:"wind_back"`             // Share of the wind felt by the smallest flakes, which seem furthest away
+	WindMiddle    float64 `toml:"wind_middle" json:"wind_middle"`         // Share of the wind felt by middling flakes
+	WindFront     float64 `toml:"wind_front" json:"wind_front"`           // Share of the wind felt by the biggest flakes, which seem closest
+	WindLagBack   float64 `toml:"wind_lag_back" json:"wind_lag_back"`     // Seconds changes in the wind take to reach the smallest flakes
+	WindLagMiddle float64 `toml:"wind_lag_middle" json:"wind_lag_middle"` // Seconds changes in the wind take to reach middling flakes
+	WindLagFront  float64 `toml:"wind_lag_front" json:"wind_lag_front"`   // Seconds changes in the wind take to reach the biggest flakes
+
+	SpriteFolder string                    `toml:"sprite_folder" json:"sprite_folder"`         // Folder of PNG images drawn as the flakes with the custom flake style
+	Sprites      map[string]SpriteSettings `toml:"sprites,omitempty" json:"sprites,omitempty"` // Settings for images in sprite_folder, by file name without .png
+
+	Glyphs    map[string]float64 `toml:"glyphs,omitempty" json:"glyphs,omitempty"` // Characters or emoji drawn with the glyph flake style, each with its chance of being picked; empty for ❄ ❅ ❆
+	GlyphFont string             `toml:"glyph_font" json:"glyph_font"`             // Installed font to draw glyphs with before trying the built-in one, empty for none
+
+	OpacityVariation float64 `toml:"opacity_variation" json:"opacity_variation"` // How much fainter than opacity some flakes are, from 0 to 1
+	Twinkle          float64 `toml:"twinkle" json:"twinkle"`                     // How deeply flakes shimmer, from 0 for steady to 1
+
+	MeltDistance float64 `toml:"melt_distance" json:"melt_distance"` // Pixels above the bottom of the screen over which flakes fade and shrink away, 0 to keep them whole
+	TrailLength  int     `toml:"trail_length" json:"trail_length"`   // Frames a fading trail lingers behind each flake, 0 for none
+	Clumping     float64 `toml:"clumping" json:"clumping"`           // Chance of flakes that touch in the air sticking together, from 0 to 1
+
+	CursorRadius   float64 `toml:"cursor_radius" json:"cursor_radius"`     // How close to the mouse pointer flakes are stirred up, in pixels
+	CursorStrength float64 `toml:"cursor_strength" json:"cursor_strength"` // How hard moving the mouse pushes flakes about, 0 to leave them be
+	SnowOnCursor   bool    `toml:"snow_on_cursor" json:"snow_on_cursor"`   // Let a little snow settle on the mouse pointer while it is still
+	CursorBreath   bool    `toml:"cursor_breath" json:"cursor_breath"`     // Breathe little puffs of mist out beside the mouse pointer while it is still
+
+	Autostart         bool `toml:"autostart" json:"autostart"`                     // Start winsnow when the user logs in
+	Service           bool `toml:"service" json:"service"`                         // Start at login under a supervisor that restarts winsnow if it crashes
+	Wallpaper         bool `toml:"wallpaper" json:"wallpaper"`                     // Draw behind the desktop icons instead of as a bottom-most window
+	ClickThrough      bool `toml:"click_through" json:"click_through"`             // Let mouse clicks pass through to the windows and icons underneath
+	AllMonitors       bool `toml:"all_monitors" json:"all_monitors"`               // Span the snow across every monitor instead of just one
+	WorkArea          bool `toml:"work_area" json:"work_area"`                     // Keep the snow above the taskbar so it lands on its edge
+	PauseOnFullscreen bool `toml:"pause_on_fullscreen" json:"pause_on_fullscreen"` // Pause while a game or video is fullscreen
+
+	PauseOnPresentation bool   `toml:"pause_on_presentation" json:"pause_on_presentation"` // Pause during presentations and on projectors
+	AlwaysAnimate       bool   `toml:"always_animate" json:"always_animate"`               // Keep the snow moving even with Windows animations turned off
+	OnBattery           string `toml:"on_battery" json:"on_battery"`                       // On battery or battery saver: normal, throttle or pause
+	LowCostRemote       bool   `toml:"low_cost_remote" json:"low_cost_remote"`             // Throttle over Remote Desktop and in virtual machines
+	AdaptiveQuality     bool   `toml:"adaptive_quality" json:"adaptive_quality"`           // Draw fewer flakes and drop fog and piles while the machine can't keep up
+	OnFocusAssist       string `toml:"on_focus_assist" json:"on_focus_assist"`             // While Focus Assist is on: normal, calm or pause
+	Notifications       bool   `toml:"notifications" json:"notifications"`                 // Show a notification when storms start and clear
+	HailSound           bool   `toml:"hail_sound" json:"hail_sound"`                       // Play a tap as hailstones land
+
+	TPS int `toml:"tps" json:"tps"` // Simulation updates a second; the physics is scaled so the snow keeps its pace at any rate
+	FPS int `toml:"fps" json:"fps"` // Most frames drawn a second, 0 to draw every frame the display shows
+
+	Stars         string  `toml:"stars" json:"stars"`                   // Night sky of twinkling stars: off, behind the snow, or alone with nothing falling
+	ShootingStars float64 `toml:"shooting_stars" json:"shooting_stars"` // Average shooting stars a minute across the night sky
+
+	Moon   bool    `toml:"moon" json:"moon"`     // Show the moon in its current phase behind the snow
+	Clouds float64 `toml:"clouds" json:"clouds"` // How thick the clouds drifting across the sky behind the snow are, from 0 for none to 1
+
+	Lightning           bool    `toml:"lightning" json:"lightning"`                       // Flash lightning during storms; off by default for photosensitive users
+	LightningEvery      float64 `toml:"lightning_every" json:"lightning_every"`           // Average seconds between strikes at the height of a storm
+	LightningBrightness float64 `toml:"lightning_brightness" json:"lightning_brightness"` // How bright the flashes are, from 0 to 1
+
+	Aurora           bool    `toml:"aurora" json:"aurora"`                       // Waving curtains of northern lights along the top of the screen
+	AuroraBrightness float64 `toml:"aurora_brightness" json:"aurora_brightness"` // How bright the northern lights are, from 0 to 1
+
+	DayNight  bool    `toml:"day_night" json:"day_night"` // Tint the flakes and haze for the time of day: blue at night, warm at dawn and dusk, white at noon
+	Sunrise   string  `toml:"sunrise" json:"sunrise"`     // Time of day as HH:MM the sun rises, unless latitude and longitude are set
+	Sunset    string  `toml:"sunset" json:"sunset"`       // Time of day as HH:MM the sun sets, unless latitude and longitude are set
+	Latitude  float64 `toml:"latitude" json:"latitude"`   // Where you are in degrees north, to work out sunrise and sunset; 0 with longitude 0 for the fixed times
+	Longitude float64 `toml:"longitude" json:"longitude"` // Where you are in degrees east, negative for west
+
+	Fog        string  `toml:"fog" json:"fog"`                 // Mist drifting across the screen: off, under or over the snow
+	FogDensity float64 `toml:"fog_density" json:"fog_density"` // How thick the mist is, from 0 to 1
+
+	Sleigh bool `toml:"sleigh" json:"sleigh"` // Fly Santa's sleigh across the sky now and then on Christmas Eve and Christmas Day
+
+	Plow      bool    `toml:"plow" json:"plow"`             // Send a snow plow along the bottom of the screen now and then to clear the snow on the ground
+	PlowEvery float64 `toml:"plow_every" json:"plow_every"` // Minutes between plows
+
+	FireworksNewYear bool     `toml:"fireworks_new_year" json:"fireworks_new_year"` // Put on a fireworks show at midnight on New Year's Eve
+	FireworksAt      []string `toml:"fireworks_at" json:"fireworks_at"`             // Times of day as HH:MM to put on a fireworks show
+	FireworksMinutes float64  `toml:"fireworks_minutes" json:"fireworks_minutes"`   // Minutes a fireworks show lasts
+
+	Seed int `toml:"seed" json:"seed"` // Random seed for a reproducible snowfall, 0 for a different one each run
+
+	Schedule Schedule `toml:"schedule" json:"schedule"` // Hours and days the snow is active
+	Weather  Weather  `toml:"weather" json:"weather"`   // Long cycle of clear skies, snow and storms
+
+	Hotkeys        map[string]string `toml:"hotkeys" json:"hotkeys"`                 // Global key combination for each hotkey action
+	HotkeysPersist bool              `toml:"hotkeys_persist" json:"hotkeys_persist"` // Save changes made with hotkeys
+
+	HUD bool `toml:"hud" json:"hud"` // Show the current conditions in the top right corner, for tuning and screenshots
+
+	Surprise        bool    `toml:"surprise" json:"surprise"`                 // Randomize the weather every few minutes
+	SurpriseMinutes float64 `toml:"surprise_minutes" json:"surprise_minutes"` // Minutes between surprises
+
+	BlizzardCycle   bool    `toml:"blizzard_cycle" json:"blizzard_cycle"`     // Let blizzards blow in now and then on their own
+	BlizzardEvery   float64 `toml:"blizzard_every" json:"blizzard_every"`     // Average minutes between random blizzards
+	BlizzardMinutes float64 `toml:"blizzard_minutes" json:"blizzard_minutes"` // Minutes a blizzard rages at full strength
+
+	PileOnTaskbar bool    `toml:"pile_on_taskbar" json:"pile_on_taskbar"` // Let snow pile up along the top of the taskbar
+	PileOnWindows bool    `toml:"pile_on_windows" json:"pile_on_windows"` // Let snow pile up on top of open windows
+	PileMaxDepth  float64 `toml:"pile_max_depth" json:"pile_max_depth"`   // Deepest a pile of snow can get in pixels
+	MeltRate      float64 `toml:"melt_rate" json:"melt_rate"`             // Pixels of piled snow that melt away each second
+	Drips         bool    `toml:"drips" json:"drips"`                     // Let meltwater drip now and then from the snow lying on things
+	Avalanches    bool    `toml:"avalanches" json:"avalanches"`           // Let drifts that grow too deep on a window collapse off its edge
+	SnowDrift     float64 `toml:"snow_drift" json:"snow_drift"`           // How strongly the wind sculpts piled snow into drifts, 0 for an even layer
+
+	Icicles          bool `toml:"icicles" json:"icicles"`                       // Grow icicles along the top of each monitor that drip and now and then break off
+	IciclesOnWindows bool `toml:"icicles_on_windows" json:"icicles_on_windows"` // Grow icicles from the top edges of open windows too
+
+	Frost        bool    `toml:"frost" json:"frost"`                 // Let frost creep in from the corners of the screen while it snows
+	FrostMinutes float64 `toml:"frost_minutes" json:"frost_minutes"` // Minutes of steady snow the frost takes to grow all the way in
+
+	GroundSnow     bool    `toml:"ground_snow" json:"ground_snow"`           // Let snow build up into drifts along the bottom of the screen
+	GroundMaxDepth float64 `toml:"ground_max_depth" json:"ground_max_depth"` // Deepest the snow on the ground can get in pixels
+	Footprints     bool    `toml:"footprints" json:"footprints"`             // Let the mouse pointer and windows dragged through the snow on the ground leave a trail pressed into it
+	GroundFog      float64 `toml:"ground_fog" json:"ground_fog"`             // How thick the haze over deep snow on the ground gets, from 0 for none to 1
+
+	Preset    string            `toml:"preset,omitempty" json:"preset,omitempty"`   // Preset applied before the rest of the file
+	Intensity int               `toml:"intensity" json:"intensity"`                 // Overall strength from 0 to 100 applied after the preset, -1 for none
+	Presets   map[string]Preset `toml:"presets,omitempty" json:"presets,omitempty"` // User-defined presets
+
+	// Per-monitor settings, keyed by monitor index ("0", "1", ...) or device name
+	Monitors map[string]MonitorConfig `toml:"monitors,omitempty" json:"monitors,omitempty"`
+}
+
+// Size of the screen flake counts are given for while density is set
+const referenceMegapixels = 1920 * 1080 / 1e6
+
+// DefaultConfig returns the settings used when no config file is present
+func DefaultConfig() Config {
+	return Config{
+		Version: configVersion,
+
+		Flakes:        300,
+		Density:       300 / referenceMegapixels,
+		SpeedMin:      6.0,
+		SpeedMax:      16.0,
+		SizeMin:       1.0,
+		SizeMax:       4.0,
+		SizeDist:      sizeUniform,
+		SizeSpread:    0.75,
+		SizeTail:      2.0,
+		WindBack:      0.6,
+		WindMiddle:    1.0,
+		WindFront:     1.4,
+		WindLagBack:   2.0,
+		WindLagMiddle: 0.8,
+		MeltDistance:  40,
+		Wind:          0.8,
+		GravityY:      1,
+		WindChangeMin: 60,
+		WindChangeMax: 180,
+		Gusts:         1.0,
+		GustSize:      400,
+		Wobble:        1.0,
+		Turbulence:    0.5,
+		Color:         "#ffffff",
+		Palette:       "classic",
+		Opacity:       1.0,
+		FlakeStyle:    flakeStyleCrystal,
+		Glow:          0.4,
+		Effect:        "snow",
+		Backdrop:      backdropNone,
+		BackdropTint:  "#c8dcf0",
+		FollowTheme:   true,
+		Intensity:     noIntensity,
+		ClickThrough:  true,
+
+		OpacityVariation: 0.4,
+		Twinkle:          0.3,
+		Clumping:         0.3,
+
+		PauseOnFullscreen:   true,
+		PauseOnPresentation: true,
+		OnBattery:           batteryThrottle,
+		LowCostRemote:       true,
+		AdaptiveQuality:     true,
+		TPS:                 60,
+		OnFocusAssist:       focusCalm,
+
+		Stars:         starsOff,
+		ShootingStars: 2,
+
+		AuroraBrightness: 0.6,
+
+		LightningEvery:      20,
+		LightningBrightness: 0.5,
+
+		Sunrise: "07:00",
+		Sunset:  "17:00",
+
+		Fog:        fogOff,
+		FogDensity: 0.3,
+
+		Sleigh:           true,
+		PlowEvery:        60,
+		FireworksNewYear: true,
+		FireworksMinutes: 2,
+
+		CursorRadius:   120,
+		CursorStrength: 1.0,
+		SnowOnCursor:   true,
+		CursorBreath:   true,
+
+		SurpriseMinutes: 10,
+		BlizzardEvery:   60,
+		BlizzardMinutes: 2,
+		PileOnTaskbar:   true,
+		PileOnWindows:   true,
+		PileMaxDepth:    12,
+		MeltRate:        0.03,
+		Drips:           true,
+		SnowDrift:       1.0,
+		Avalanches:      true,
+		GroundSnow:      true,
+		GroundMaxDepth:  40,
+		Footprints:      true,
+		Icicles:         true,
+		FrostMinutes:    30,
+		GroundFog:       0.4,
+		Hotkeys:         DefaultHotkeys(),
+		Weather: Weather{
+			Clear:    20,
+			Light:    15,
+			Heavy:    20,
+			Blizzard: 3,
+			Clearing: 10,
+		},
+	}
+}
+
+// Set at startup to keep all settings beside the executable
+// instead of in %APPDATA% and the registry
+var portable bool
+
+// Name of the config file, both in %APPDATA%\winsnow and in portable mode
+const configFileName = "config.toml"
+
+// DataDir returns the directory where settings and state are kept:
+// %APPDATA%\winsnow normally, or the executable's directory in portable mode
+func DataDir() (string, error) {
+	if portable {
+		exe, err := os.Executable()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Dir(exe), nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "winsnow"), nil
+}
+
+// ConfigPath returns the location of the config file
+func ConfigPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// HasPortableConfig reports whether a config file sits beside the executable,
+// which switches on portable mode without needing the flag
+func HasPortableConfig() bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(filepath.Dir(exe), configFileName))
+	return err == nil
+}
+
+// LoadConfig reads the config file at path on top of the defaults and any
+// settings saved in the registry. A missing file is not an error; those
+// base settings are returned instead.
+func LoadConfig(path string) (Config, error) {
+	return loadConfig(path, true)
+}
+
+// ReadConfig reads and validates a config file like LoadConfig, but
+// upgrades an old file only in memory, never writing to disk
+func ReadConfig(path string) (Config, error) {
+	return loadConfig(path, false)
+}
+
+// loadConfig reads a config file, upgrading an old one and saving the
+// upgrade if migrate is set
+func loadConfig(path string, migrate bool) (Config, error) {
+	base := baseConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return base, nil
+		}
+		return base, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	// Bring files from older releases up to date before reading them
+	if migrate {
+		data, err = migrateConfig(path, data)
+	} else {
+		data, _, err = upgradeConfig(path, data)
+	}
+	if err != nil {
+		log.Println("Could not upgrade config file:", err)
+	}
+
+	cfg := base
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return base, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	// Start again from the preset and intensity so explicit keys in the file
+	// can still tweak them
+	if cfg.Preset != "" || cfg.Intensity != noIntensity {
+		file := cfg
+		cfg = base
+		cfg.Presets = file.Presets
+		if file.Preset != "" {
+			if err := cfg.ApplyPreset(file.Preset); err != nil {
+				return base, fmt.Errorf("invalid config %s: %w", path, err)
+			}
+		}
+		if file.Intensity != noIntensity {
+			if err := cfg.ApplyIntensity(file.Intensity); err != nil {
+				return base, fmt.Errorf("invalid config %s: %w", path, err)
+			}
+		}
+		toml.Decode(string(data), &cfg)
+	}
+	if md.IsDefined("flakes") && !md.IsDefined("density") {
+		cfg.SetFlakes(cfg.Flakes)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return base, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// baseConfig returns the defaults with any settings saved in the registry applied
+func baseConfig() Config {
+	cfg := DefaultConfig()
+	if err := LoadRegistry(&cfg); err != nil {
+		log.Println("Could not read settings from the registry:", err)
+		return DefaultConfig()
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Println("Ignoring settings in the registry:", err)
+		return DefaultConfig()
+	}
+	return cfg
+}
+
+// SaveConfig writes cfg to path, creating the directory if needed
+func SaveConfig(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	// A density that only follows flakes is left out, so editing flakes by
+	// hand still changes the count the next time the file is read
+	if cfg.densityFollows() {
+		data = densityLine.ReplaceAll(data, nil)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// The top-level density key in an encoded config
+var densityLine = regexp.MustCompile(`(?m)^density = .*\n`)
+
+// SaveSettings persists cfg to the registry and, if path is set, the config file
+func SaveSettings(path string, cfg Config) error {
+	err := SaveRegistry(cfg)
+	if path != "" {
+		err = errors.Join(err, SaveConfig(path, cfg))
+	}
+	return err
+}
+
+// SetFlakes sets the number of flakes. While the count is scaled to the
+// screen, the density follows so there are n flakes on a 1920x1080 screen.
+func (c *Config) SetFlakes(n int) {
+	c.Flakes = n
+	if c.Density > 0 {
+		c.Density = float64(n) / referenceMegapixels
+	}
+}
+
+// densityFollows reports whether the density is the one SetFlakes derives
+// from the flake count, rather than one the user chose
+func (c Config) densityFollows() bool {
+	return c.Density > 0 && c.Density == float64(c.Flakes)/referenceMegapixels
+}
+
+// Validate checks that the settings are usable
+func (c *Config) Validate() error {
+	switch {
+	case c.Flakes < 0:
+		return fmt.Errorf("flakes must not be negative, got %d", c.Flakes)
+	case c.Glow < 0 || c.Glow > 1:
+		return fmt.Errorf("glow must be between 0 and 1, got %g", c.Glow)
+	case c.TPS < 10 || c.TPS > 240:
+		return fmt.Errorf("tps must be between 10 and 240, got %d", c.TPS)
+	case c.FPS < 0:
+		return fmt.Errorf("fps must not be negative, got %d", c.FPS)
+	case c.Density < 0:
+		return fmt.Errorf("density must not be negative, got %g", c.Density)
+	case c.SpeedMin <= 0 || c.SpeedMax < c.SpeedMin:
+		return fmt.Errorf("speed range %g-%g is invalid", c.SpeedMin, c.SpeedMax)
+	case c.SizeMin <= 0 || c.SizeMax < c.SizeMin:
+		return fmt.Errorf("size range %g-%g is invalid", c.SizeMin, c.SizeMax)
+	case c.SizeSpread <= 0:
+		return fmt.Errorf("size_spread must be positive, got %g", c.SizeSpread)
+	case c.SizeTail <= 0:
+		return fmt.Errorf("size_tail must be positive, got %g", c.SizeTail)
+	case c.WindBack < 0 || c.WindMiddle < 0 || c.WindFront < 0:
+		return fmt.Errorf("wind_back, wind_middle and wind_front must not be negative")
+	case c.WindLagBack < 0 || c.WindLagMiddle < 0 || c.WindLagFront < 0:
+		return fmt.Errorf("wind_lag_back, wind_lag_middle and wind_lag_front must not be negative")
+	case c.GravityX == 0 && c.GravityY == 0:
+		return fmt.Errorf("gravity_x and gravity_y must not both be 0")
+	case c.Clumping < 0 || c.Clumping > 1:
+		return fmt.Errorf("clumping must be between 0 and 1, got %g", c.Clumping)
+	case c.TrailLength < 0:
+		return fmt.Errorf("trail_length must not be negative, got %d", c.TrailLength)
+	case c.MeltDistance < 0:
+		return fmt.Errorf("melt_distance must not be negative, got %g", c.MeltDistance)
+	case c.Wind < 0:
+		return fmt.Errorf("wind must not be negative, got %g", c.Wind)
+	case c.WindChangeMin <= 0 || c.WindChangeMax < c.WindChangeMin:
+		return fmt.Errorf("wind change range %g-%g is invalid", c.WindChangeMin, c.WindChangeMax)
+	case c.Gusts < 0:
+		return fmt.Errorf("gusts must not be negative, got %g", c.Gusts)
+	case c.GustSize <= 0:
+		return fmt.Errorf("gust_size must be positive, got %g", c.GustSize)
+	case c.Wobble < 0:
+		return fmt.Errorf("wobble must not be negative, got %g", c.Wobble)
+	case c.Turbulence < 0:
+		return fmt.Errorf("turbulence must not be negative, got %g", c.Turbulence)
+	case c.Opacity <= 0 || c.Opacity > 1:
+		return fmt.Errorf("opacity must be between 0 and 1, got %g", c.Opacity)
+	case c.OpacityVariation < 0 || c.OpacityVariation > 1:
+		return fmt.Errorf("opacity_variation must be between 0 and 1, got %g", c.OpacityVariation)
+	case c.Twinkle < 0 || c.Twinkle > 1:
+		return fmt.Errorf("twinkle must be between 0 and 1, got %g", c.Twinkle)
+	case c.Intensity != noIntensity && (c.Intensity < 0 || c.Intensity > 100):
+		return fmt.Errorf("intensity must be between 0 and 100, got %d", c.Intensity)
+	case c.SurpriseMinutes <= 0:
+		return fmt.Errorf("surprise_minutes must be positive, got %g", c.SurpriseMinutes)
+	case c.BlizzardEvery <= 0:
+		return fmt.Errorf("blizzard_every must be positive, got %g", c.BlizzardEvery)
+	case c.BlizzardMinutes <= 0:
+		return fmt.Errorf("blizzard_minutes must be positive, got %g", c.BlizzardMinutes)
+	case c.PileMaxDepth < 0:
+		return fmt.Errorf("pile_max_depth must not be negative, got %g", c.PileMaxDepth)
+	case c.GroundMaxDepth < 0:
+		return fmt.Errorf("ground_max_depth must not be negative, got %g", c.GroundMaxDepth)
+	case c.CursorRadius <= 0:
+		return fmt.Errorf("cursor_radius must be positive, got %g", c.CursorRadius)
+	case c.CursorStrength < 0:
+		return fmt.Errorf("cursor_strength must not be negative, got %g", c.CursorStrength)
+	case c.SnowDrift < 0:
+		return fmt.Errorf("snow_drift must not be negative, got %g", c.SnowDrift)
+	case c.MeltRate < 0:
+		return fmt.Errorf("melt_rate must not be negative, got %g", c.MeltRate)
+	case c.Clouds < 0 || c.Clouds > 1:
+		return fmt.Errorf("clouds must be between 0 and 1, got %g", c.Clouds)
+	case c.ShootingStars < 0:
+		return fmt.Errorf("shooting_stars must not be negative, got %g", c.ShootingStars)
+	case c.PlowEvery <= 0:
+		return fmt.Errorf("plow_every must be positive, got %g", c.PlowEvery)
+	case c.FrostMinutes <= 0:
+		return fmt.Errorf("frost_minutes must be positive, got %g", c.FrostMinutes)
+	case c.GroundFog < 0 || c.GroundFog > 1:
+		return fmt.Errorf("ground_fog must be between 0 and 1, got %g", c.GroundFog)
+	case c.LightningEvery <= 0:
+		return fmt.Errorf("lightning_every must be positive, got %g", c.LightningEvery)
+	case c.LightningBrightness < 0 || c.LightningBrightness > 1:
+		return fmt.Errorf("lightning_brightness must be between 0 and 1, got %g", c.LightningBrightness)
+	case c.AuroraBrightness < 0 || c.AuroraBrightness > 1:
+		return fmt.Errorf("aurora_brightness must be between 0 and 1, got %g", c.AuroraBrightness)
+	case c.FogDensity < 0 || c.FogDensity > 1:
+		return fmt.Errorf("fog_density must be between 0 and 1, got %g", c.FogDensity)
+	case c.FireworksMinutes <= 0:
+		return fmt.Errorf("fireworks_minutes must be positive, got %g", c.FireworksMinutes)
+	case c.Monitor < 0:
+		return fmt.Errorf("monitor must not be negative, got %d", c.Monitor)
+	}
+	if _, err := ParseColor(c.Color); err != nil {
+		return err
+	}
+	if _, err := ParseColor(c.BackdropTint); err != nil {
+		return err
+	}
+	if err := validateBackdrop(c.Backdrop); err != nil {
+		return err
+	}
+	if err := validateFlakeStyle(c.FlakeStyle); err != nil {
+		return err
+	}
+	if c.FlakeStyle == flakeStyleCustom && c.SpriteFolder == "" {
+		return fmt.Errorf("flake_style %s needs sprite_folder to be set", flakeStyleCustom)
+	}
+	if err := validateGlyphs(c.Glyphs); err != nil {
+		return err
+	}
+	for name, s := range c.Sprites {
+		if err := s.Validate(name); err != nil {
+			return err
+		}
+	}
+	if err := validatePalette(c.Palette); err != nil {
+		return err
+	}
+	if err := validateEffect(c.Effect); err != nil {
+		return err
+	}
+	if err := validateStars(c.Stars); err != nil {
+		return err
+	}
+	if err := validateFog(c.Fog); err != nil {
+		return err
+	}
+	if err := validateFireworksAt(c.FireworksAt); err != nil {
+		return err
+	}
+	if err := c.validateDaylight(); err != nil {
+		return err
+	}
+	if err := validateSizeDistribution(c.SizeDist); err != nil {
+		return err
+	}
+	if err := c.Schedule.Validate(); err != nil {
+		return err
+	}
+	if err := c.Weather.Validate(); err != nil {
+		return err
+	}
+	if err := validateOnBattery(c.OnBattery); err != nil {
+		return err
+	}
+	if err := validateOnFocusAssist(c.OnFocusAssist); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResolveConfig loads the config file at path and applies the environment
+// and command-line overrides. Problems with the file are logged and the
+// defaults used instead; an error is only returned if the overrides leave
+// the settings unusable.
+func ResolveConfig(path string, flags *Flags) (Config, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		log.Println("Using default settings:", err)
+	}
+
+	return cfg, cfg.ApplyOverrides(flags)
+}
+
+// ApplyOverrides applies the WINSNOW_* environment variables and then the
+// command-line flags on top of the config file settings
+func (c *Config) ApplyOverrides(flags *Flags) error {
+	apply := func() error {
+		if err := ApplyEnv(c); err != nil {
+			return err
+		}
+		return flags.Apply(c)
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+
+	// Per-monitor sections are part of the file, but which one applies is only
+	// known once the overrides have picked the monitor, so apply it and then
+	// the overrides again to keep them on top
+	if len(c.Monitors) > 0 {
+		if err := c.ApplyMonitorSection(c.Monitor, monitorName(c.Monitor)); err != nil {
+			return err
+		}
+		if err := apply(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return nil
+}
+
+// ParseColor parses a #rrggbb hex color
+func ParseColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("color %q must be in #rrggbb form", s)
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("color %q must be in #rrggbb form", s)
+	}
+	c.A = 255
+	return c, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Shape of the generated crystals
+const (
+	crystalDesigns  = 128 // Number of different crystals generated at startup; flakes share them
+	crystalColumns  = 16  // Crystals per row of the generated atlas
+	crystalSamples  = 3   // Subsamples per pixel along each axis, for smooth edges
+	crystalBranches = 4   // Most pairs of side branches on each arm
+)
+
+// crystalSegment is a line of ice in a crystal, centered on the origin
+type crystalSegment struct {
+	x0, y0, x1, y1 float64
+	width          float64
+}
+
+// distance returns how far the point is from the segment
+func (s crystalSegment) distance(x, y float64) float64 {
+	dx, dy := s.x1-s.x0, s.y1-s.y0
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = max(0, min(1, ((x-s.x0)*dx+(y-s.y0)*dy)/l))
+	}
+	return math.Hypot(x-s.x0-t*dx, y-s.y0-t*dy)
+}
+
+// crystal is the geometry of one generated snowflake
+type crystal struct {
+	segments []crystalSegment
+	plate    float64 // Radius of the solid hexagon in the middle, 0 for none
+}
+
+// newCrystal grows a random dendrite: one arm with mirrored side branches,
+// repeated six times around the middle
+func newCrystal(r *rand.Rand) crystal {
+	radius := spriteCell/2 - 1.5
+	length := radius * (0.7 + 0.3*r.Float64())
+	width := 1 + r.Float64()
+
+	arm := []crystalSegment{{0, 0, length, 0, width}}
+	for i := r.Intn(crystalBranches) + 1; i > 0; i-- {
+		at := length * (0.2 + 0.7*r.Float64())
+		size := (length - at) * (0.3 + 0.6*r.Float64())
+		angle := math.Pi / 3 * (0.7 + 0.5*r.Float64())
+		bx, by := at+size*math.Cos(angle), size*math.Sin(angle)
+		w := width * (0.5 + 0.4*r.Float64())
+		arm = append(arm, crystalSegment{at, 0, bx, by, w}, crystalSegment{at, 0, bx, -by, w})
+	}
+
+	c := crystal{}
+	if r.Intn(3) == 0 {
+		c.plate = length * (0.15 + 0.2*r.Float64())
+	}
+	for k := 0; k < 6; k++ {
+		sin, cos := math.Sincos(float64(k) * math.Pi / 3)
+		for _, s := range arm {
+			c.segments = append(c.segments, crystalSegment{
+				s.x0*cos - s.y0*sin, s.x0*sin + s.y0*cos,
+				s.x1*cos - s.y1*sin, s.x1*sin + s.y1*cos,
+				s.width,
+			})
+		}
+	}
+	return c
+}
+
+// covers reports whether the point lies on the crystal
+func (c crystal) covers(x, y float64) bool {
+	if c.plate > 0 {
+		// Distance to a hexagon's edge depends on the angle from its nearest corner
+		a := math.Mod(math.Atan2(y, x)+2*math.Pi, math.Pi/3) - math.Pi/6
+		if math.Hypot(x, y)*math.Cos(a) <= c.plate {
+			return true
+		}
+	}
+	for _, s := range c.segments {
+		if s.distance(x, y) <= s.width/2 {
+			return true
+		}
+	}
+	return false
+}
+
+// rasterize draws the crystal in white into the cell at (ox, oy)
+func (c crystal) rasterize(img *image.NRGBA, ox, oy int) {
+	for py := 0; py < spriteCell; py++ {
+		for px := 0; px < spriteCell; px++ {
+			hits := 0
+			for sy := 0; sy < crystalSamples; sy++ {
+				for sx := 0; sx < crystalSamples; sx++ {
+					x := float64(px) + (float64(sx)+0.5)/crystalSamples - spriteCell/2
+					y := float64(py) + (float64(sy)+0.5)/crystalSamples - spriteCell/2
+					if c.covers(x, y) {
+						hits++
+					}
+				}
+			}
+			a := uint8(hits * 255 / (crystalSamples * crystalSamples))
+			img.SetNRGBA(ox+px, oy+py, color.NRGBA{255, 255, 255, a})
+		}
+	}
+}
+
+// The atlas the crystals were last generated into
+var crystalAtlas *ebiten.Image
+
+// GenerateCrystals grows a set of different crystals and rasterizes them
+// into a single atlas, so they are drawn as cheaply as the built-in sprites.
+//
+// The set is a deliberate cap rather than one crystal per flake: a few
+// thousand flakes share crystalDesigns shapes, so some fall with the same
+// one. Each is turned to its own angle and scaled to its own size, which
+// hides the repeats, while one fixed atlas keeps startup quick and the
+// whole field in a handful of draw calls however many flakes there are.
+func GenerateCrystals(r *rand.Rand) []*ebiten.Image {
+	rows := (crystalDesigns + crystalColumns - 1) / crystalColumns
+	img := image.NewNRGBA(image.Rect(0, 0, crystalColumns*spriteCell, rows*spriteCell))
+	for i := 0; i < crystalDesigns; i++ {
+		newCrystal(r).rasterize(img, i%crystalColumns*spriteCell, i/crystalColumns*spriteCell)
+	}
+
+	crystalAtlas = ebiten.NewImageFromImage(img)
+	crystals := make([]*ebiten.Image, crystalDesigns)
+	for i := range crystals {
+		x, y := i%crystalColumns*spriteCell, i/crystalColumns*spriteCell
+		crystals[i] = crystalAtlas.SubImage(image.Rect(x, y, x+spriteCell, y+spriteCell)).(*ebiten.Image)
+	}
+	return crystals
+}
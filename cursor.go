@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"unsafe"
+)
+
+// How the mouse stirs up the snow
+const (
+	cursorSmoothing = 0.5  // Share of the previous speed kept when the cursor moves, to even out jerky mice
+	cursorMinSpeed  = 2.0  // Slowest cursor movement in pixels per frame that disturbs the snow
+	cursorDrag      = 0.3  // Share of the cursor's movement passed on to flakes beside it
+	cursorSwirl     = 0.15 // Sideways push away from the cursor's path for each pixel per frame it moves
+	cursorCarry     = 0.3  // Share of the push kept as drift so flakes coast on afterwards
+)
+
+// Snow settling on the mouse pointer
+const (
+	cursorSettle   = 30   // Frames the pointer must be still before snow settles on it
+	cursorWidth    = 12.0 // Width of the top of the arrow pointer in pixels
+	cursorMaxDepth = 6.0  // Deepest the snow on the pointer can get
+)
+
+// Cursor is where the mouse pointer is on the snow, in game coordinates
+type Cursor struct {
+	x, y   float64
+	vx, vy float64 // Smoothed movement per frame
+	known  bool    // Whether the position was read successfully last frame
+	still  int     // Frames the pointer has not moved for
+	pile   *Pile   // Snow lying on the pointer, nil while it is moving
+}
+
+// trackCursor reads the mouse position from Windows, since with clicks
+// passing through the window Ebiten never sees it
+func (g *Game) trackCursor() {
+	hwnd := FindGameWindow()
+	if hwnd == 0 {
+		g.cursor.known = false
+		return
+	}
+
+	var pt struct{ x, y int32 }
+	var window rect
+	ok, _, _ := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&window)))
+	if ok == 0 || window.right <= window.left {
+		g.cursor.known = false
+		return
+	}
+
+	scale := float64(g.screenWidth) / float64(window.right-window.left)
+	x := float64(pt.x-window.left) * scale
+	y := float64(pt.y-window.top) * scale
+	if g.cursor.known && x == g.cursor.x && y == g.cursor.y {
+		g.cursor.still++
+	} else {
+		g.cursor.still = 0
+	}
+	if g.cursor.known {
+		g.cursor.vx = g.cursor.vx*cursorSmoothing + (x-g.cursor.x)*(1-cursorSmoothing)
+		g.cursor.vy = g.cursor.vy*cursorSmoothing + (y-g.cursor.y)*(1-cursorSmoothing)
+	} else {
+		g.cursor.vx, g.cursor.vy = 0, 0
+	}
+	g.cursor.x, g.cursor.y, g.cursor.known = x, y, true
+}
+
+// stir pushes a flake near a fast-moving cursor c, dragging it along
+// behind the pointer and swirling it out of the way. Smaller flakes are
+// thrown further.
+func (g *Game) stir(f *Snowflake, c Cursor) {
+	speed := math.Hypot(c.vx, c.vy)
+	if !c.known || g.config.CursorStrength == 0 || speed < cursorMinSpeed {
+		return
+	}
+
+	dx, dy := f.x-c.x, f.y-c.y
+	dist := math.Hypot(dx, dy)
+	if dist >= g.config.CursorRadius || dist == 0 {
+		return
+	}
+	strength := g.config.CursorStrength * (1 - dist/g.config.CursorRadius) / f.size
+
+	// Drag along with the cursor, and push out to the side of its path
+	px := (c.vx*cursorDrag + dx/dist*speed*cursorSwirl) * strength
+	py := (c.vy*cursorDrag + dy/dist*speed*cursorSwirl) * strength
+	f.x += px
+	f.y += py
+	f.drift += px * cursorCarry
+}
+
+// updateCursorPile lets snow settle on the pointer once it has been still
+// for a moment, and knocks it off as soon as the pointer moves
+func (g *Game) updateCursorPile() {
+	c := &g.cursor
+	if c.pile != nil && (!c.known || c.still == 0 || !g.config.SnowOnCursor) {
+		g.knockOff(c.pile)
+		c.pile = nil
+	}
+	if c.pile == nil && c.known && c.still >= cursorSettle && g.config.SnowOnCursor {
+		c.pile = NewPile(surfaceCursor, c.x, c.y, cursorWidth)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// SpriteSettings tunes how one image in sprite_folder is used
+type SpriteSettings struct {
+	Weight   float64 `toml:"weight" json:"weight"`       // How often the image is picked relative to the others, 0 for the usual 1
+	ScaleMin float64 `toml:"scale_min" json:"scale_min"` // Smallest the image is drawn, times its own size, 0 for the usual 1
+	ScaleMax float64 `toml:"scale_max" json:"scale_max"` // Largest the image is drawn, times its own size, 0 for the same as scale_min
+	Upright  bool    `toml:"upright" json:"upright"`     // Keep the image upright instead of letting it spin
+}
+
+// Validate checks the settings for one image
+func (s SpriteSettings) Validate(name string) error {
+	switch {
+	case s.Weight < 0:
+		return fmt.Errorf("sprites.%s: weight must not be negative, got %g", name, s.Weight)
+	case s.ScaleMin < 0 || s.ScaleMax < 0:
+		return fmt.Errorf("sprites.%s: scale must not be negative", name)
+	case s.ScaleMax > 0 && s.ScaleMax < s.ScaleMin:
+		return fmt.Errorf("sprites.%s: scale range %g-%g is invalid", name, s.ScaleMin, s.ScaleMax)
+	}
+	return nil
+}
+
+// customSprite is an image loaded from sprite_folder or a rendered glyph, with its settings filled in
+type customSprite struct {
+	image    *ebiten.Image
+	weight   float64
+	scaleMin float64
+	scaleMax float64
+	upright  bool
+}
+
+// LoadCustomSprites loads every PNG image in folder, applying the settings
+// for each by file name, without its extension
+func LoadCustomSprites(folder string, settings map[string]SpriteSettings) ([]customSprite, error) {
+	paths, err := filepath.Glob(filepath.Join(folder, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var sprites []customSprite
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		s := settings[name]
+		sprite := customSprite{
+			image:    ebiten.NewImageFromImage(img),
+			weight:   s.Weight,
+			scaleMin: s.ScaleMin,
+			scaleMax: s.ScaleMax,
+			upright:  s.Upright,
+		}
+		if sprite.weight == 0 {
+			sprite.weight = 1
+		}
+		if sprite.scaleMin == 0 {
+			sprite.scaleMin = 1
+		}
+		if sprite.scaleMax == 0 {
+			sprite.scaleMax = sprite.scaleMin
+		}
+		sprites = append(sprites, sprite)
+	}
+	if len(sprites) == 0 {
+		return nil, fmt.Errorf("no PNG images in %s", folder)
+	}
+	return sprites, nil
+}
+
+// updateCustomSprites loads the images in sprite_folder or renders the
+// glyphs when their flake style is picked or their settings change
+func (g *Game) updateCustomSprites() {
+	var key string
+	switch g.config.FlakeStyle {
+	case flakeStyleCustom:
+		key = fmt.Sprint(g.config.SpriteFolder, g.config.Sprites)
+	case flakeStyleGlyph:
+		key = fmt.Sprint(g.config.GlyphFont, g.config.Glyphs)
+	default:
+		g.customSprites = nil
+		g.customKey = ""
+		return
+	}
+	if g.customSprites != nil && g.customKey == key {
+		return
+	}
+
+	var sprites []customSprite
+	var err error
+	if g.config.FlakeStyle == flakeStyleCustom {
+		sprites, err = LoadCustomSprites(g.config.SpriteFolder, g.config.Sprites)
+	} else {
+		sprites, err = LoadGlyphs(g.config.Glyphs, g.config.GlyphFont)
+	}
+	if err != nil {
+		log.Println("Could not load the flake images:", err)
+	}
+	g.customSprites = sprites
+	g.customKey = key
+
+	// Give the flakes already falling one of the new images
+	for i := range g.snowflakes {
+		g.pickSprite(&g.snowflakes[i], g.rng)
+	}
+}
+
+// pickSprite gives a flake one of the custom images or glyphs, picked by weight, and a size to draw it at
+func (g *Game) pickSprite(f *Snowflake, r *rand.Rand) {
+	if len(g.customSprites) == 0 {
+		return
+	}
+	total := 0.0
+	for _, s := range g.customSprites {
+		total += s.weight
+	}
+	pick := r.Float64() * total
+	for i, s := range g.customSprites {
+		if pick -= s.weight; pick < 0 || i == len(g.customSprites)-1 {
+			f.design = i
+			f.scale = s.scaleMin + r.Float64()*(s.scaleMax-s.scaleMin)
+			return
+		}
+	}
+}
+
+// drawCustom draws a flake as its custom image in the image's own colors,
+// faded to the given opacity
+func (g *Game) drawCustom(screen *ebiten.Image, f Snowflake, alpha uint8) {
+	s := g.customSprites[f.design%len(g.customSprites)]
+	bounds := s.image.Bounds()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(bounds.Dx())/2, -float64(bounds.Dy())/2)
+	if !s.upright {
+		op.GeoM.Rotate(f.angle)
+	}
+	op.GeoM.Scale(f.scale, f.scale)
+	op.GeoM.Translate(f.x, f.y)
+	op.ColorScale.ScaleAlpha(float32(alpha) / 255)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(s.image, op)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Minutes either side of sunrise and sunset the light is warm
+const twilightMinutes = 60.0
+
+// Tints laid over the flakes and haze through the day, as red, green and blue multipliers
+var (
+	nightTint    = [3]float64{0.70, 0.80, 1.00} // Cool blue
+	twilightTint = [3]float64{1.00, 0.88, 0.78} // Warm grey
+	noonTint     = [3]float64{1.00, 1.00, 1.00} // Bright white
+)
+
+// validateDaylight checks the sunrise, sunset and location settings
+func (c *Config) validateDaylight() error {
+	rise, err := parseClock(c.Sunrise)
+	if err != nil {
+		return fmt.Errorf("sunrise %q must be in HH:MM form", c.Sunrise)
+	}
+	set, err := parseClock(c.Sunset)
+	if err != nil {
+		return fmt.Errorf("sunset %q must be in HH:MM form", c.Sunset)
+	}
+	switch {
+	case rise >= set:
+		return fmt.Errorf("sunrise %s must be before sunset %s", c.Sunrise, c.Sunset)
+	case c.Latitude < -90 || c.Latitude > 90:
+		return fmt.Errorf("latitude must be between -90 and 90, got %g", c.Latitude)
+	case c.Longitude < -180 || c.Longitude > 180:
+		return fmt.Errorf("longitude must be between -180 and 180, got %g", c.Longitude)
+	}
+	return nil
+}
+
+// sunTimes returns the minutes after midnight the sun rises and sets on
+// the day of t, worked out from the latitude and longitude if they are set
+// and otherwise as configured. Where the sun doesn't set they are a whole
+// day apart, and where it doesn't rise they are equal.
+func (c *Config) sunTimes(t time.Time) (float64, float64) {
+	if c.Latitude == 0 && c.Longitude == 0 {
+		rise, _ := parseClock(c.Sunrise)
+		set, _ := parseClock(c.Sunset)
+		return float64(rise), float64(set)
+	}
+
+	// How far the sun is north or south of the equator, and how long it
+	// stays up at this latitude because of it
+	day := float64(t.YearDay())
+	declination := -23.44 * math.Pi / 180 * math.Cos(2*math.Pi/365*(day+10))
+	latitude := c.Latitude * math.Pi / 180
+	hours := math.Acos(max(-1, min(1, -math.Tan(latitude)*math.Tan(declination)))) * 180 / math.Pi / 15
+
+	// Noon by the sun, in local clock time, corrected for the eccentric orbit
+	b := 2 * math.Pi * (day - 81) / 364
+	equation := 9.87*math.Sin(2*b) - 7.53*math.Cos(b) - 1.5*math.Sin(b)
+	_, offset := t.Zone()
+	noon := 720 - 4*c.Longitude - equation + float64(offset)/60
+	return noon - hours*60, noon + hours*60
+}
+
+// dayTint returns the tint for the light at time t: cool blue at night,
+// warm grey around sunrise and sunset and white in the middle of the day
+func (c *Config) dayTint(t time.Time) [3]float64 {
+	if !c.DayNight {
+		return noonTint
+	}
+	rise, set := c.sunTimes(t)
+	switch {
+	case set-rise >= 24*60:
+		return noonTint
+	case set <= rise:
+		return nightTint
+	}
+
+	now := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	target := nightTint
+	if now > rise && now < set {
+		target = noonTint
+	}
+	// Warmest right at sunrise and sunset, fading to day or night over the twilight
+	w := min(math.Min(math.Abs(now-rise), math.Abs(now-set))/twilightMinutes, 1)
+	var tint [3]float64
+	for i := range tint {
+		tint[i] = twilightTint[i] + (target[i]-twilightTint[i])*w
+	}
+	return tint
+}
+
+// tintColor returns a #rrggbb color with the tint laid over it
+func tintColor(s string, tint [3]float64) string {
+	c, err := ParseColor(s)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("#%02x%02x%02x", uint8(float64(c.R)*tint[0]), uint8(float64(c.G)*tint[1]), uint8(float64(c.B)*tint[2]))
+}
+
+// updateDaylight recolors the flakes and backdrop once a minute as the
+// light changes through the day
+func (g *Game) updateDaylight() {
+	if !g.config.DayNight {
+		return
+	}
+	minute := time.Now().Truncate(time.Minute)
+	if minute.Equal(g.dayChecked) {
+		return
+	}
+	g.dayChecked = minute
+	g.updateColor()
+	if cfg := g.displayConfig(g.config); cfg.Backdrop != backdropNone {
+		ApplyBackdrop(FindGameWindow(), cfg)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Frames debris is given to come down before it is put out anyway
+const debrisLife = 3600
+
+// Debris falls like snow until it lands, adding to whatever it lands on.
+// It's set in init, as falling leads back to emitDebris through the effects.
+var debrisBehavior *Behavior
+
+func init() {
+	debrisBehavior = &Behavior{drag: 1, move: fallDebris, draw: drawDebris}
+}
+
+// emitDebris throws f into the air as debris: a clump knocked off a pile, a
+// fragment split off a clump, a bounce or a piece of an icicle. Debris is
+// in the frame the snow falls in, so it has a pool of its own drawn with
+// the flakes, and doesn't count toward the number of flakes.
+func (g *Game) emitDebris(f Snowflake) {
+	g.debris.Emit(Particle{
+		x:      f.x,
+		y:      f.y,
+		vx:     f.vx,
+		vy:     f.vy,
+		size:   f.size,
+		speed:  f.speed,
+		life:   debrisLife,
+		span:   debrisLife,
+		behave: debrisBehavior,
+	})
+}
+
+// flake returns the particle as a flake, to move and draw it the way the
+// snow is
+func (p Particle) flake() Snowflake {
+	return Snowflake{x: p.x, y: p.y, size: p.size, speed: p.speed, vx: p.vx, vy: p.vy, angle: p.angle}
+}
+
+// fallDebris lets gravity and the wind work on debris, and puts it out once
+// it has landed or left the screen
+func fallDebris(g *Game, p *Particle) {
+	prevY := p.y - p.vy
+	f := p.flake()
+	wx, wy := g.windAt(f.x, f.y, g.config.depthLayer(&f))
+	g.fall(&f, wx, wy)
+	p.vx, p.vy = f.vx, f.vy
+
+	_, bottom := g.fallSize()
+	if g.land(&f, prevY) || f.y > bottom {
+		p.life = 0
+	}
+}
+
+// drawDebris draws debris as a round dot, melting away near the bottom of
+// the screen like the snow
+func drawDebris(g *Game, screen *ebiten.Image, p Particle) {
+	f := p.flake()
+	c := g.flakeTint(f)
+	if left := g.melting(f); left < 1 {
+		if left <= 0 {
+			return
+		}
+		f.size *= left
+		c.A = uint8(float64(c.A) * left)
+	}
+	g.drawDot(screen, f, c)
+}
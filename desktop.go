@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for following foreground window changes
+const (
+	EVENT_SYSTEM_FOREGROUND = 0x0003
+	WINEVENT_OUTOFCONTEXT   = 0x0000
+	SW_SHOWNOACTIVATE       = 4
+)
+
+// Desktop window classes that come to the front on Show Desktop (Win+D)
+var desktopClasses = map[string]bool{
+	"Progman": true,
+	"WorkerW": true,
+}
+
+// onForegroundChange is the SetWinEventHook callback. It re-places the
+// window at once rather than waiting for the next positioning tick, so
+// Show Desktop doesn't leave the snow hidden.
+var onForegroundChange = windows.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, time uintptr) uintptr {
+	// A window attached to the wallpaper moves with the desktop by itself
+	if own := FindGameWindow(); own != 0 {
+		if parent, _, _ := procGetParent.Call(own); parent == 0 {
+			SetWindowToBottom()
+		}
+	}
+	return 0
+})
+
+// WatchShowDesktop keeps the snow in view when the user shows the desktop.
+// Call it from the message window's setup function, whose message loop
+// delivers the events.
+func WatchShowDesktop() {
+	if hook, _, err := procSetWinEventHook.Call(EVENT_SYSTEM_FOREGROUND, EVENT_SYSTEM_FOREGROUND,
+		0, onForegroundChange, 0, 0, WINEVENT_OUTOFCONTEXT); hook == 0 {
+		log.Println("Could not watch for Show Desktop:", err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Constants for display and work area change notifications
+const (
+	WM_DISPLAYCHANGE = 0x007E // Monitors were added, removed or changed resolution
+	WM_SETTINGCHANGE = 0x001A
+	SPI_SETWORKAREA  = 0x002F // The taskbar was moved or resized
+)
+
+// WatchDisplays refits the snow to the monitors whenever the display layout
+// or the work area changes. Call it from the message window's setup function.
+func WatchDisplays(w *MessageWindow, game *Game) {
+	// Waits on the game, so it's run off the message loop
+	refit := func() {
+		var cfg Config
+		game.Call(func(g *Game) { cfg = g.config })
+
+		// Fullscreen windows are resized by Ebiten itself
+		if hwnd := FindGameWindow(); hwnd != 0 && (cfg.AllMonitors || cfg.WorkArea || cfg.Wallpaper) {
+			FitWindow(hwnd, cfg)
+		}
+		game.Post(func(g *Game) { g.Relayout() })
+	}
+
+	w.Handle(WM_DISPLAYCHANGE, func(wParam, lParam uintptr) uintptr {
+		go refit()
+		return 0
+	})
+	w.Handle(WM_SETTINGCHANGE, func(wParam, lParam uintptr) uintptr {
+		if wParam == SPI_SETWORKAREA {
+			go refit()
+		}
+		return 0
+	})
+}
+
+// displayBounds returns the area the snow should cover in physical pixels.
+// Ebiten only supports a single window, so rather than one window per
+// monitor the snow spanning all monitors falls on one surface across the
+// whole virtual desktop, which keeps the wind and drifting flakes
+// continuous from one screen to the next. Otherwise it covers the monitor
+// the window is on, or the primary monitor before the window exists. With
+// the work area option the taskbar is left out, so snow lands on its edge
+// instead of vanishing behind it.
+func displayBounds(hwnd uintptr, cfg Config) rect {
+	if cfg.AllMonitors {
+		return VirtualDesktop(cfg.WorkArea)
+	}
+
+	monitor, _, _ := procMonitorFromWindow.Call(hwnd, MONITOR_DEFAULTTOPRIMARY)
+	if hwnd != 0 {
+		monitor, _, _ = procMonitorFromWindow.Call(hwnd, MONITOR_DEFAULTTONEAREST)
+	}
+	var mi monitorInfo
+	mi.cbSize = uint32(unsafe.Sizeof(mi))
+	if ok, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&mi))); ok == 0 {
+		return rect{}
+	}
+	return monitorBounds(mi, cfg.WorkArea)
+}
+
+// FitWindow moves the window to cover the area the settings ask for, so it
+// is not left at a stale size or position
+func FitWindow(hwnd uintptr, cfg Config) {
+	if r := displayBounds(hwnd, cfg); r.right > r.left {
+		placeWindow(hwnd, r)
+	}
+}
+
+// screenSize returns the size of the area the snow covers
+func (g *Game) screenSize() (int, int) {
+	if g.config.AllMonitors || g.config.WorkArea {
+		if r := displayBounds(FindGameWindow(), g.config); r.right > r.left {
+			return int(r.right - r.left), int(r.bottom - r.top)
+		}
+	}
+	return ebiten.ScreenSizeInFullscreen()
+}
+
+// Relayout resizes the screen to the current display layout after a
+// resolution change or rotation. Flakes keep their place relative to the
+// screen, so they neither fall outside it nor bunch up in one corner.
+func (g *Game) Relayout() {
+	oldWidth, oldHeight := g.fallSize()
+	g.screenWidth, g.screenHeight = g.screenSize()
+	width, height := g.fallSize()
+	if oldWidth == width && oldHeight == height {
+		return
+	}
+
+	if oldWidth > 0 && oldHeight > 0 {
+		scaleX := width / oldWidth
+		scaleY := height / oldHeight
+		for i := range g.snowflakes {
+			g.snowflakes[i].x *= scaleX
+			g.snowflakes[i].y *= scaleY
+			g.snowflakes[i].px *= scaleX
+			g.snowflakes[i].py *= scaleY
+		}
+		for i := range g.debris.particles {
+			g.debris.particles[i].x *= scaleX
+			g.debris.particles[i].y *= scaleY
+		}
+	}
+	g.updateGround()
+	g.resizeFlakes() // Keep the density the same on the new screen
+	g.frozen = false // Redraw at the new size even while paused
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How meltwater drips from the snow lying on things
+const (
+	dripChance   = 0.02 // Chance each frame a full pile drips, less for shallower snow
+	dripMinDepth = 2.0  // Shallowest snow that drips, in pixels
+	dripTake     = 0.5  // Pixels of snow each drip takes away
+	dripSpeed    = 0.4  // Pixels per frame a drip runs down
+	dripLifeMin  = 40   // Fewest frames a drip runs before it dries up
+	dripLifeMax  = 100  // Most frames a drip runs before it dries up
+)
+
+// Color of the meltwater
+var dripColor = color.NRGBA{0xc8, 0xe0, 0xff, 0xb0}
+
+// Drips run slowly down and dry up, over the snow
+var dripBehavior = &Behavior{drag: 1, front: true, draw: drawDrip}
+
+// dripPiles now and then lets a drop of meltwater run down from the snow
+// on an edge, taking a little of the snow with it
+func (g *Game) dripPiles(r *rand.Rand) {
+	if !g.config.Drips || g.config.MeltRate <= 0 {
+		return
+	}
+	for _, p := range g.piles {
+		col := r.Intn(len(p.depth))
+		maxDepth := g.maxDepth(p.kind)
+		if p.depth[col] < dripMinDepth || maxDepth <= 0 || r.Float64() >= dripChance*p.depth[col]/maxDepth {
+			continue
+		}
+		p.depth[col] -= dripTake
+
+		// Off the edge down the face of a window, or down into the snow on the ground
+		y := p.y
+		if p.kind == surfaceGround {
+			y = p.top(col)
+		}
+		life := dripLifeMin + r.Intn(dripLifeMax-dripLifeMin)
+		g.particles.Emit(Particle{
+			x:      p.x + (float64(col)+0.5)*pileColumnWidth,
+			y:      y,
+			vy:     dripSpeed * (0.5 + r.Float64()*0.5),
+			size:   1.2,
+			life:   life,
+			span:   life,
+			color:  dripColor,
+			behave: dripBehavior,
+		})
+	}
+}
+
+// drawDrip draws a drip as a short bead of water fading as it dries up
+func drawDrip(g *Game, screen *ebiten.Image, p Particle) {
+	c := p.color
+	c.A = uint8(float64(c.A) * (1 - p.age()))
+	vector.StrokeLine(screen, float32(p.x), float32(p.y-3), float32(p.x), float32(p.y), float32(p.size), c, true)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Effect is a kind of weather the particles can show. Every effect shares
+// the same flakes, wind and physics; it only changes how fast they fall,
+// how they are drawn and what happens when they land.
+type Effect struct {
+	color   string  // Default particle color as #rrggbb, empty to keep the snow's
+	opacity float64 // Opacity relative to the configured opacity
+	speed   float64 // Fall speed relative to the configured speeds
+	piles   bool    // Whether particles pile up where they land
+	melts   bool    // Whether particles fade away near the bottom of the screen
+	sway    float64 // How far particles rock from side to side relative to snow
+	spiral  float64 // How far particles bob up and down as they sway, turning the sway into a spiral
+	calm    float64 // Share of the wind particles ignore
+	cold    bool    // Whether it is cold enough for frost to form
+
+	// draw draws a particle, nil to draw it like snow
+	draw func(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA)
+
+	// impact is called where a particle lands, nil for nothing
+	impact func(g *Game, f *Snowflake)
+
+	// move moves a particle a frame instead of letting it fall, nil to fall like snow
+	move func(g *Game, f *Snowflake, r *rand.Rand)
+}
+
+// Names of the effects, in the order they are offered
+var effectNames = []string{"snow", "rain", "sleet", "hail", "leaves", "petals", "fireflies", "confetti"}
+
+// The available effects by name
+var effects = map[string]*Effect{
+	"snow":      {opacity: 1, speed: 1, sway: 1, piles: true, melts: true, cold: true},
+	"rain":      {color: "#a8bcd8", opacity: 0.6, speed: rainSpeed, draw: drawRaindrop, impact: splash},
+	"sleet":     {color: "#c8d4e4", opacity: 0.8, speed: sleetSpeed, draw: drawSleet, impact: sleetImpact, cold: true},
+	"hail":      {color: "#e8f0ff", opacity: 0.9, speed: hailSpeed, draw: drawHailstone, impact: hailImpact, cold: true},
+	"leaves":    {opacity: 1, speed: leafSpeed, sway: leafSway, draw: drawLeaf, impact: settleLeaf},
+	"petals":    {opacity: 0.9, speed: petalSpeed, sway: petalSway, spiral: petalSpiral, calm: petalCalm, draw: drawPetal, melts: true},
+	"fireflies": {color: "#d4ff6a", opacity: 1, draw: drawFirefly, move: wander},
+	"confetti":  {opacity: 1, speed: confettiSpeed, sway: confettiSway, draw: drawConfetti},
+}
+
+// validateEffect checks the effect setting
+func validateEffect(name string) error {
+	if _, ok := effects[name]; !ok {
+		return fmt.Errorf("effect must be one of %s, got %q", strings.Join(effectNames, ", "), name)
+	}
+	return nil
+}
+
+// effect returns the effect currently showing
+func (g *Game) effect() *Effect {
+	if e, ok := effects[g.config.Effect]; ok {
+		return e
+	}
+	return effects["snow"]
+}
+
+// SetEffect switches to the named effect at runtime
+func (g *Game) SetEffect(name string) error {
+	if err := validateEffect(name); err != nil {
+		return err
+	}
+	cfg := g.config
+	cfg.Effect = name
+	g.ApplyConfig(cfg)
+	return nil
+}
+
+// drawFlake draws a particle in the current effect's style
+func (g *Game) drawFlake(screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	switch e := g.effect(); {
+	case e.draw != nil:
+		e.draw(g, screen, f, c)
+	case g.config.FlakeStyle == flakeStyleCustom && len(g.customSprites) > 0:
+		g.drawCustom(screen, f, c.A)
+	case g.config.FlakeStyle == flakeStyleGlyph && len(g.customSprites) > 0:
+		g.drawGlyph(screen, f, c)
+	case g.config.FlakeStyle == flakeStyleDot,
+		g.config.FlakeStyle == flakeStyleShader && snowFailed:
+		g.drawDot(screen, f, c)
+	case g.config.FlakeStyle == flakeStyleShader:
+		g.drawSoft(screen, f, c)
+	default:
+		g.drawSprite(screen, f, c)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the environment variables that override config values,
+// e.g. WINSNOW_FLAKES or WINSNOW_SPEED_MAX
+const envPrefix = "WINSNOW_"
+
+// envName returns the environment variable for a TOML key
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// ApplyEnv overrides cfg with any WINSNOW_* environment variables that are set
+func ApplyEnv(cfg *Config) error {
+	// The preset and intensity go first so the individual variables can tweak them
+	if name := os.Getenv(envName("preset")); name != "" {
+		if err := cfg.ApplyPreset(name); err != nil {
+			return fmt.Errorf("%s: %w", envName("preset"), err)
+		}
+	}
+	if s := os.Getenv(envName("intensity")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%s=%q is not a valid value", envName("intensity"), s)
+		}
+		if err := cfg.ApplyIntensity(n); err != nil {
+			return fmt.Errorf("%s: %w", envName("intensity"), err)
+		}
+	}
+
+	var err error
+	forEachSetting(cfg, func(key string, v reflect.Value) {
+		name := envName(key)
+		s, ok := os.LookupEnv(name)
+		if !ok || key == "preset" || key == "intensity" {
+			return
+		}
+
+		var perr error
+		switch v.Kind() {
+		case reflect.Int:
+			var n int64
+			if n, perr = strconv.ParseInt(s, 10, 64); perr == nil {
+				v.SetInt(n)
+			}
+		case reflect.Bool:
+			var b bool
+			if b, perr = strconv.ParseBool(s); perr == nil {
+				v.SetBool(b)
+			}
+		case reflect.Float64:
+			var f float64
+			if f, perr = strconv.ParseFloat(s, 64); perr == nil {
+				v.SetFloat(f)
+			}
+		case reflect.String:
+			v.SetString(s)
+		}
+		if perr != nil && err == nil {
+			err = fmt.Errorf("%s=%q is not a valid value", name, s)
+		}
+	})
+
+	// A flake count on its own scales with the screen like the default does
+	_, flakes := os.LookupEnv(envName("flakes"))
+	_, density := os.LookupEnv(envName("density"))
+	if flakes && !density {
+		cfg.SetFlakes(cfg.Flakes)
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How fireflies wander and glow
+const (
+	fireflyWander = 0.08 // Random push each frame in pixels per frame
+	fireflyDamp   = 0.97 // Share of its speed a firefly keeps from one frame to the next
+	fireflyMax    = 1.2  // Fastest a firefly flies in pixels per frame
+	fireflyShy    = 150  // How close to the mouse pointer fireflies fly away, in pixels
+	fireflyFlee   = 0.3  // How hard fireflies fly away from the pointer
+	fireflyScale  = 6.0  // The glow is drawn this many times the flake size
+)
+
+// The soft round glow of a firefly, made on first use
+var glowSprite *ebiten.Image
+
+// makeGlow draws a bright middle fading smoothly out to nothing
+func makeGlow() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, spriteCell, spriteCell))
+	half := float64(spriteCell) / 2
+	for py := 0; py < spriteCell; py++ {
+		for px := 0; px < spriteCell; px++ {
+			d := math.Hypot(float64(px)+0.5-half, float64(py)+0.5-half) / half
+			a := math.Max(0, 1-d)
+			img.SetNRGBA(px, py, color.NRGBA{255, 255, 255, uint8(a * a * 255)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// wander moves a firefly a frame along its random path, steering it away
+// from the mouse pointer and keeping it on the screen
+func wander(g *Game, f *Snowflake, r *rand.Rand) {
+	f.vx = f.vx*fireflyDamp + r.NormFloat64()*fireflyWander
+	f.vy = f.vy*fireflyDamp + r.NormFloat64()*fireflyWander
+
+	if c := g.fallCursor(); c.known {
+		dx, dy := f.x-c.x, f.y-c.y
+		if d := math.Hypot(dx, dy); d > 0 && d < fireflyShy {
+			push := fireflyFlee * (1 - d/fireflyShy)
+			f.vx += dx / d * push
+			f.vy += dy / d * push
+		}
+	}
+
+	if speed := math.Hypot(f.vx, f.vy); speed > fireflyMax {
+		f.vx *= fireflyMax / speed
+		f.vy *= fireflyMax / speed
+	}
+	f.x += f.vx
+	f.y += f.vy
+
+	// Turn back at the top and bottom; the sides wrap around like snow
+	if _, h := g.fallSize(); f.y < 0 || f.y > h {
+		f.vy = -f.vy
+		f.y = max(0, min(f.y, h))
+	}
+}
+
+// drawFirefly draws a firefly as a glow that slowly pulses, adding its
+// light to whatever is behind it
+func drawFirefly(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	if glowSprite == nil {
+		glowSprite = makeGlow()
+	}
+	pulse := math.Max(0, math.Sin(f.shimmer))
+	brightness := 0.1 + 0.9*pulse*pulse
+
+	scale := f.size * fireflyScale / spriteCell
+	op := &ebiten.DrawImageOptions{Blend: ebiten.BlendLighter}
+	op.GeoM.Translate(-spriteCell/2, -spriteCell/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(f.x, f.y)
+	op.ColorScale.ScaleWithColor(c)
+	op.ColorScale.Scale(float32(brightness), float32(brightness), float32(brightness), float32(brightness))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(glowSprite, op)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How rockets fly and burst
+const (
+	rocketGravity  = 0.15 // Pull on a rocket in pixels per frame per frame
+	rocketSpeedMin = 9.0  // Slowest a rocket is launched, in pixels per frame
+	rocketSpeedMax = 13.0 // Fastest a rocket is launched, in pixels per frame
+	rocketGapMin   = 10   // Fewest frames between launches
+	rocketGapMax   = 45   // Most frames between launches
+	sparkCount     = 70   // Sparks thrown out by each burst
+	sparkSpeed     = 4.0  // Fastest a spark flies out, in pixels per frame
+	sparkGravity   = 0.05 // Pull on a spark in pixels per frame per frame
+	sparkDrag      = 0.97 // Share of its speed a spark keeps from one frame to the next
+	sparkLifeMin   = 50   // Fewest frames a spark glows for
+	sparkLifeMax   = 90   // Most frames a spark glows for
+	sparkHot       = 0.2  // Share of its life a spark burns white before showing its color
+)
+
+// Colors the bursts are picked from
+var fireworkPalette = []color.NRGBA{
+	{0xff, 0x4d, 0x4d, 0xff}, // Red
+	{0xff, 0xb3, 0x1a, 0xff}, // Gold
+	{0x6b, 0xff, 0x6b, 0xff}, // Green
+	{0x4d, 0xb8, 0xff, 0xff}, // Blue
+	{0xd0, 0x6b, 0xff, 0xff}, // Purple
+	{0xff, 0xff, 0xff, 0xff}, // White
+}
+
+// rocket climbs from the bottom of the screen until it slows to a stop and bursts
+type rocket struct {
+	x, y   float64
+	vx, vy float64
+	color  color.NRGBA // Color of the sparks it bursts into
+}
+
+// Sparks of a burst fall, slow and fade
+var sparkBehavior = &Behavior{gravity: sparkGravity, drag: sparkDrag, draw: drawSpark}
+
+// Fireworks is a show of rockets bursting over the snow
+type Fireworks struct {
+	rockets []rocket
+	until   time.Time // When the show stops launching rockets
+	next    int       // Frames until the next launch
+	checked time.Time // Minute last checked for a scheduled show
+}
+
+// validateFireworksAt checks the times of day fireworks are scheduled for
+func validateFireworksAt(times []string) error {
+	for _, t := range times {
+		if _, err := parseClock(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartFireworks starts a show now, or makes the current one last longer
+func (g *Game) StartFireworks() {
+	g.fireworks.until = time.Now().Add(minutes(g.config.FireworksMinutes))
+}
+
+// fireworksDue reports whether a show should start in the minute t
+func (c *Config) fireworksDue(t time.Time) bool {
+	if c.FireworksNewYear && t.Month() == time.January && t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 {
+		return true
+	}
+	now := t.Hour()*60 + t.Minute()
+	for _, at := range c.FireworksAt {
+		if clock, err := parseClock(at); err == nil && clock == now {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFireworks starts scheduled shows, launches rockets while a show is
+// on and moves the rockets already in the air
+func (g *Game) updateFireworks(r *rand.Rand) {
+	fw := &g.fireworks
+	now := time.Now()
+
+	// Check each minute once, so a show is not restarted all minute long
+	if minute := now.Truncate(time.Minute); !minute.Equal(fw.checked) {
+		fw.checked = minute
+		if g.config.fireworksDue(now) {
+			g.StartFireworks()
+		}
+	}
+
+	if now.Before(fw.until) {
+		if fw.next--; fw.next <= 0 {
+			fw.launch(g.screenWidth, g.screenHeight, r)
+			fw.next = rocketGapMin + r.Intn(rocketGapMax-rocketGapMin)
+		}
+	}
+
+	for i := range fw.rockets {
+		k := &fw.rockets[i]
+		k.x += k.vx
+		k.y += k.vy
+		k.vy += rocketGravity
+		if k.vy >= 0 {
+			fw.burst(*k, &g.particles, r)
+		}
+	}
+	fw.rockets = slices.DeleteFunc(fw.rockets, func(k rocket) bool { return k.vy >= 0 })
+}
+
+// launch sends a rocket up from somewhere along the bottom of the screen
+func (fw *Fireworks) launch(width, height int, r *rand.Rand) {
+	fw.rockets = append(fw.rockets, rocket{
+		x:     float64(width) * (0.15 + 0.7*r.Float64()),
+		y:     float64(height),
+		vx:    (r.Float64()*2 - 1) * 1.5,
+		vy:    -(rocketSpeedMin + r.Float64()*(rocketSpeedMax-rocketSpeedMin)),
+		color: fireworkPalette[r.Intn(len(fireworkPalette))],
+	})
+}
+
+// burst throws out a ball of sparks where a rocket has stopped climbing
+func (fw *Fireworks) burst(k rocket, pool *Pool, r *rand.Rand) {
+	for range sparkCount {
+		angle := r.Float64() * 2 * math.Pi
+		// Most sparks fly out near full speed, making a clear shell
+		speed := sparkSpeed * math.Sqrt(r.Float64())
+		life := sparkLifeMin + r.Intn(sparkLifeMax-sparkLifeMin)
+		pool.Emit(Particle{
+			x:      k.x,
+			y:      k.y,
+			vx:     math.Cos(angle) * speed,
+			vy:     math.Sin(angle) * speed,
+			life:   life,
+			span:   life,
+			color:  k.color,
+			behave: sparkBehavior,
+		})
+	}
+}
+
+// drawFireworks draws the rockets climbing
+func (g *Game) drawFireworks(screen *ebiten.Image) {
+	for _, k := range g.fireworks.rockets {
+		trail := color.NRGBA{0xff, 0xd0, 0x80, 0x80}
+		vector.StrokeLine(screen, float32(k.x), float32(k.y), float32(k.x-k.vx*2), float32(k.y-k.vy*2), 1.5, trail, true)
+		vector.DrawFilledCircle(screen, float32(k.x), float32(k.y), 1.5, color.White, true)
+	}
+}
+
+// drawSpark draws a spark of a burst, which burns white-hot, cools to its
+// color and fades away
+func drawSpark(g *Game, screen *ebiten.Image, p Particle) {
+	age := p.age()
+	c := p.color
+	if age < sparkHot {
+		// Blend from white to the spark's color as it cools
+		heat := 1 - age/sparkHot
+		c.R = uint8(float64(c.R) + (255-float64(c.R))*heat)
+		c.G = uint8(float64(c.G) + (255-float64(c.G))*heat)
+		c.B = uint8(float64(c.B) + (255-float64(c.B))*heat)
+	}
+	c.A = uint8(255 * min(1, 2*(1-age)))
+	vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), 1.2, c, true)
+}
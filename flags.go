@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+)
+
+// Flags holds the command-line overrides for the config file
+type Flags struct {
+	Portable    bool // Keep settings beside the executable
+	CheckConfig bool // Print the resolved settings and exit
+
+	fs     *flag.FlagSet
+	values Config
+}
+
+// NewFlags registers the command-line flags
+func NewFlags() *Flags {
+	f := &Flags{fs: flag.NewFlagSet("winsnow", flag.ExitOnError)}
+	def := DefaultConfig()
+
+	f.fs.IntVar(&f.values.Flakes, "flakes", def.Flakes, "number of snowflakes")
+	f.fs.Float64Var(&f.values.Density, "density", def.Density, "snowflakes per megapixel of screen, instead of a fixed number")
+	f.fs.Float64Var(&f.values.SpeedMin, "speed-min", def.SpeedMin, "slowest fall speed in pixels per frame")
+	f.fs.Float64Var(&f.values.SpeedMax, "speed-max", def.SpeedMax, "fastest fall speed in pixels per frame")
+	f.fs.Float64Var(&f.values.Wind, "wind", def.Wind, "maximum wind strength")
+	f.fs.Float64Var(&f.values.SizeMax, "size", def.SizeMax, "largest flake diameter in pixels")
+	f.fs.BoolVar(&f.values.Surprise, "surprise", false, "randomize the weather every few minutes")
+	f.fs.IntVar(&f.values.Seed, "seed", 0, "random seed for a reproducible snowfall (0 picks one at random)")
+	f.fs.BoolVar(&f.values.AlwaysAnimate, "animate", false, "keep the snow moving even with Windows animations turned off")
+	f.fs.BoolVar(&f.Portable, "portable", false, "keep settings beside the executable instead of in %APPDATA% and the registry")
+	f.fs.BoolVar(&f.CheckConfig, "check-config", false, "validate the config, print the effective settings after overrides and exit")
+	f.fs.StringVar(&f.values.Preset, "preset", "", "intensity preset (calm, flurry, blizzard or one from the config file)")
+	f.fs.IntVar(&f.values.Intensity, "intensity", noIntensity, "overall strength from 0 to 100, scaling flakes, speed, wind and opacity together")
+
+	return f
+}
+
+// Parse parses the command-line arguments (without the program name)
+func (f *Flags) Parse(args []string) error {
+	return f.fs.Parse(args)
+}
+
+// Apply copies every flag that was explicitly set into cfg,
+// leaving the config file values alone for the rest
+func (f *Flags) Apply(cfg *Config) error {
+	// The preset and intensity go first so the individual flags can tweak them
+	if f.values.Preset != "" {
+		if err := cfg.ApplyPreset(f.values.Preset); err != nil {
+			return err
+		}
+	}
+	if f.values.Intensity != noIntensity {
+		if err := cfg.ApplyIntensity(f.values.Intensity); err != nil {
+			return err
+		}
+	}
+
+	density := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "flakes":
+			cfg.SetFlakes(f.values.Flakes)
+		case "density":
+			density = true
+		case "speed-min":
+			cfg.SpeedMin = f.values.SpeedMin
+		case "speed-max":
+			cfg.SpeedMax = f.values.SpeedMax
+		case "wind":
+			cfg.Wind = f.values.Wind
+		case "size":
+			cfg.SizeMax = f.values.SizeMax
+		case "surprise":
+			cfg.Surprise = f.values.Surprise
+		case "seed":
+			cfg.Seed = f.values.Seed
+		case "animate":
+			cfg.AlwaysAnimate = f.values.AlwaysAnimate
+		}
+	})
+
+	// Flags are visited in name order, so set the density last to win over
+	// the one that came with the flake count
+	if density {
+		cfg.Density = f.values.Density
+	}
+	return nil
+}
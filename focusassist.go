@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"unsafe"
+)
+
+// What to do while Focus Assist (Do Not Disturb) is on
+const (
+	focusNormal = "normal" // Carry on as usual
+	focusCalm   = "calm"   // Switch to the calm preset
+	focusPause  = "pause"  // Stop the snow
+)
+
+// Windows has no public API for Focus Assist, so its state is read from the
+// notification facility (WNF) the shell publishes it through:
+// 0 is off, 1 is priority only and 2 is alarms only
+const wnfShelQuietHoursActiveProfileChanged uint64 = 0x0D83063EA3BF1C75
+
+// validateOnFocusAssist checks the on_focus_assist setting
+func validateOnFocusAssist(mode string) error {
+	switch mode {
+	case focusNormal, focusCalm, focusPause:
+		return nil
+	}
+	return fmt.Errorf("on_focus_assist must be %s, %s or %s, got %q", focusNormal, focusCalm, focusPause, mode)
+}
+
+// FocusAssistActive reports whether Focus Assist is silencing notifications
+func FocusAssistActive() bool {
+	stateName := wnfShelQuietHoursActiveProfileChanged
+	var changeStamp, profile uint32
+	size := uint32(unsafe.Sizeof(profile))
+	status, _, _ := procNtQueryWnfStateData.Call(
+		uintptr(unsafe.Pointer(&stateName)), 0, 0,
+		uintptr(unsafe.Pointer(&changeStamp)),
+		uintptr(unsafe.Pointer(&profile)),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	return status == 0 && profile != 0
+}
+
+// WatchFocusAssist calms or pauses the snow while Focus Assist is on,
+// checking every few seconds
+func WatchFocusAssist(game *Game) {
+	if err := procNtQueryWnfStateData.Find(); err != nil {
+		log.Println("Could not watch Focus Assist:", err)
+		return
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	for range ticker.C {
+		active := FocusAssistActive()
+		game.Post(func(g *Game) { g.SetFocusAssist(active) })
+	}
+}
+
+// SetFocusAssist applies the on_focus_assist setting when Focus Assist is
+// turned on, and undoes it when it is turned off
+func (g *Game) SetFocusAssist(active bool) {
+	if active == g.focusAssist {
+		return
+	}
+	g.focusAssist = active
+
+	// Undo what was done when it turned on, even if the setting has
+	// changed since
+	if !active {
+		g.Resume(pausedByFocusAssist)
+		if g.focusAction == focusCalm {
+			g.undoCalm()
+		}
+		g.focusAction = focusNormal
+		return
+	}
+
+	g.focusAction = g.config.OnFocusAssist
+	switch g.focusAction {
+	case focusCalm:
+		g.beforeFocus = g.config
+		if err := g.SetPreset("calm"); err != nil {
+			log.Println("Could not calm the snow for Focus Assist:", err)
+			g.focusAction = focusNormal
+		}
+	case focusPause:
+		g.Pause(pausedByFocusAssist)
+	}
+}
+
+// undoCalm puts back the settings the calm preset changed for Focus Assist.
+// Any the user has changed again since are left as they are now, as is
+// everything else changed while Focus Assist was on.
+func (g *Game) undoCalm() {
+	before := g.beforeFocus
+	calm := before
+	if err := calm.ApplyPreset("calm"); err != nil {
+		return
+	}
+	cfg := g.config
+	undo(&cfg.Preset, calm.Preset, before.Preset)
+	undo(&cfg.Flakes, calm.Flakes, before.Flakes)
+	undo(&cfg.Density, calm.Density, before.Density)
+	undo(&cfg.SpeedMin, calm.SpeedMin, before.SpeedMin)
+	undo(&cfg.SpeedMax, calm.SpeedMax, before.SpeedMax)
+	undo(&cfg.Wind, calm.Wind, before.Wind)
+	undo(&cfg.Opacity, calm.Opacity, before.Opacity)
+	g.ApplyConfig(cfg)
+}
+
+// undo sets a setting back to what it was before an override, if it still
+// has the overriding value
+func undo[T comparable](setting *T, override, before T) {
+	if *setting == override {
+		*setting = before
+	}
+}
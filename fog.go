@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Where the fog is drawn
+const (
+	fogOff   = "off"   // No fog
+	fogUnder = "under" // Behind the snow
+	fogOver  = "over"  // In front of the snow
+)
+
+// Shape of the fog and how it drifts
+const (
+	fogTextures = 4     // Different patches of mist to pick from
+	fogWidth    = 512   // Width of a patch texture in pixels
+	fogHeight   = 192   // Height of a patch texture in pixels
+	fogDetail   = 64.0  // Pixels across the largest swirls in a patch texture
+	fogOctaves  = 4     // Layers of ever finer swirls
+	fogSpacing  = 250   // Pixels of screen width per patch
+	fogScaleMin = 1.5   // Smallest a patch is drawn, times its texture size
+	fogScaleMax = 3.0   // Largest a patch is drawn, times its texture size
+	fogDriftMax = 0.3   // Fastest a patch drifts on its own in pixels per frame
+	fogWind     = 0.4   // Share of the wind that carries the fog along
+	fogHorizon  = 0.35  // Share of the way down the screen the fog starts
+	fogGrey     = 0xdd  // Red and green of the mist
+	fogBlue     = 0xee  // Blue of the mist, a little cooler than grey
+	fogPhase    = 101.7 // Offset into the noise for each patch texture
+)
+
+// fogPatch is a soft patch of mist drifting across the screen
+type fogPatch struct {
+	x, y    float64
+	scale   float64 // Times its texture size it is drawn
+	drift   float64 // Its own speed in pixels per frame, on top of the wind
+	texture int     // Which texture it uses
+}
+
+// Fog is a layer of mist drifting sideways across the screen
+type Fog struct {
+	textures []*ebiten.Image
+	patches  []fogPatch
+	width    int // Screen width the patches were spread over
+}
+
+// validateFog checks the fog setting
+func validateFog(mode string) error {
+	switch mode {
+	case fogOff, fogUnder, fogOver:
+		return nil
+	}
+	return fmt.Errorf("fog must be %s, %s or %s, got %q", fogOff, fogUnder, fogOver, mode)
+}
+
+// makeFog draws a patch of mist from layered noise, fading out toward its
+// edges so patches blend into each other
+func makeFog(n *Noise, layer float64) *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, fogWidth, fogHeight))
+	for py := 0; py < fogHeight; py++ {
+		for px := 0; px < fogWidth; px++ {
+			v, amp, freq := 0.0, 1.0, 1/fogDetail
+			for range fogOctaves {
+				v += amp * n.At(float64(px)*freq, float64(py)*freq, layer)
+				amp /= 2
+				freq *= 2
+			}
+
+			// An ellipse that is solid in the middle and clear at the edges
+			dx := (float64(px) - fogWidth/2) / (fogWidth / 2)
+			dy := (float64(py) - fogHeight/2) / (fogHeight / 2)
+			edge := max(0, 1-dx*dx-dy*dy)
+
+			a := max(0, min(1, 0.5+v)) * edge * edge
+			img.SetNRGBA(px, py, color.NRGBA{fogGrey, fogGrey, fogBlue, uint8(a * 255)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// updateFog drifts the mist along with the wind, spreading fresh patches
+// if the screen has changed size
+func (g *Game) updateFog(r *rand.Rand) {
+	fog := &g.fog
+	if g.config.Fog == fogOff {
+		fog.patches = nil
+		return
+	}
+
+	if fog.textures == nil {
+		for i := range fogTextures {
+			fog.textures = append(fog.textures, makeFog(g.noise, float64(i)*fogPhase))
+		}
+	}
+	if fog.patches == nil || fog.width != g.screenWidth {
+		fog.spread(g.screenWidth, g.screenHeight, r)
+	}
+
+	for i := range fog.patches {
+		p := &fog.patches[i]
+		p.x += p.drift + g.wind*fogWind
+		w := fogWidth * p.scale
+		if p.x > float64(g.screenWidth) {
+			p.x = -w
+		} else if p.x < -w {
+			p.x = float64(g.screenWidth)
+		}
+	}
+}
+
+// spread places patches of mist across the lower part of a screen
+func (fog *Fog) spread(width, height int, r *rand.Rand) {
+	fog.width = width
+	fog.patches = make([]fogPatch, width/fogSpacing+2)
+	for i := range fog.patches {
+		scale := fogScaleMin + r.Float64()*(fogScaleMax-fogScaleMin)
+		top := float64(height) * fogHorizon
+		fog.patches[i] = fogPatch{
+			x:       r.Float64()*float64(width+fogWidth) - fogWidth,
+			y:       top + r.Float64()*(float64(height)-top) - fogHeight*scale/2,
+			scale:   scale,
+			drift:   (r.Float64()*2 - 1) * fogDriftMax,
+			texture: r.Intn(fogTextures),
+		}
+	}
+}
+
+// drawFog draws the mist if it belongs on the given side of the snow
+func (g *Game) drawFog(screen *ebiten.Image, mode string) {
+	if g.config.Fog != mode || g.quality >= qualityNoFog {
+		return
+	}
+	for _, p := range g.fog.patches {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(p.scale, p.scale)
+		op.GeoM.Translate(p.x, p.y)
+		op.ColorScale.ScaleAlpha(float32(g.config.FogDensity))
+		g.tintHaze(&op.ColorScale)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(g.fog.textures[p.texture], op)
+	}
+}
+
+// tintHaze tints the mist for the time of day
+func (g *Game) tintHaze(scale *ebiten.ColorScale) {
+	tint := g.config.dayTint(time.Now())
+	scale.Scale(float32(tint[0]), float32(tint[1]), float32(tint[2]), 1)
+}
+
+// How the haze over the snow on the ground builds up
+const groundFogHeight = 200 // Pixels the haze reaches up when the ground snow is at its deepest
+
+// Vertical gradient from clear at the top to solid at the bottom, made on first use
+var groundFogGradient *ebiten.Image
+
+// makeGroundFog draws a one pixel wide gradient, stretched across the screen to draw the haze
+func makeGroundFog() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 256))
+	for py := range 256 {
+		t := float64(py) / 255
+		img.SetNRGBA(0, py, color.NRGBA{fogGrey, fogGrey, fogBlue, uint8(t * t * 255)})
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// drawGroundFog draws a haze along the bottom of the screen that grows
+// taller and thicker as snow builds up on the ground
+func (g *Game) drawGroundFog(screen *ebiten.Image) {
+	p, ok := g.piles[groundPile]
+	if !ok || g.config.GroundFog <= 0 || g.config.GroundMaxDepth <= 0 || g.quality >= qualityNoFog {
+		return
+	}
+	total := 0.0
+	for _, d := range p.depth {
+		total += d
+	}
+	level := min(1, total/float64(len(p.depth))/g.config.GroundMaxDepth)
+	height := groundFogHeight * level
+	if height < 1 {
+		return
+	}
+
+	if groundFogGradient == nil {
+		groundFogGradient = makeGroundFog()
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(g.screenWidth), height/256)
+	op.GeoM.Translate(0, float64(g.screenHeight)-height)
+	op.ColorScale.ScaleAlpha(float32(g.config.GroundFog * level))
+	g.tintHaze(&op.ColorScale)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(groundFogGradient, op)
+}
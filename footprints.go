@@ -0,0 +1,27 @@
+package main
+
+// How footprints are pressed into the snow on the ground
+const (
+	footprintWidth  = 10.0  // Width of the trail the mouse pointer leaves in pixels
+	footprintPack   = 0.7   // Share of the snow pressed down in the middle of a footprint
+	footprintRefill = 0.002 // Pixels a footprint fills in each tick as the snow around it settles
+)
+
+// tread presses a footprint into the snow on the ground from x to x+width
+// wherever something reaching down to the height bottom is standing in it
+func (g *Game) tread(x, bottom, width float64) {
+	p, ok := g.piles[groundPile]
+	if !ok || !g.config.Footprints || width <= 0 {
+		return
+	}
+	mid := x + width/2
+	for cx := x; cx < x+width; cx += pileColumnWidth {
+		col := p.column(cx)
+		if col < 0 || bottom < p.y-p.depth[col] {
+			continue
+		}
+		// Pressed deepest in the middle, with a rounded edge
+		t := (cx - mid) / (width / 2)
+		p.dent[col] = max(p.dent[col], p.depth[col]*footprintPack*(1-t*t))
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	_ "embed"
+	"image"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Shape of the frost and how fast it comes and goes
+const (
+	frostSize     = 384  // Width and height of the frost pattern for one corner, in pixels
+	frostReach    = 0.4  // Share of the shorter side of the screen the frost reaches in from a corner
+	frostBranches = 7    // Crystals growing out from each corner
+	frostFork     = 0.05 // Chance each pixel along a crystal that it forks
+	frostDepth    = 3    // Most times a crystal forks from the ones before it
+	frostHaze     = 0.12 // Thickness of the thin haze between the crystals
+	frostOpacity  = 0.7  // Opacity of the frost where it is thickest
+	frostMelt     = 3.0  // Times as fast as it grows that the frost melts back
+	frostWeather  = 0.2  // Least of the configured snow that keeps the frost growing
+)
+
+// Kage shader revealing the frost pattern up to how far it has grown
+//
+//go:embed assets/frost.kage
+var frostKage []byte
+
+// The compiled frost shader and the pattern it reveals, made on first use,
+// and whether the shader wouldn't compile on this GPU
+var (
+	frostShader  *ebiten.Shader
+	frostPattern *ebiten.Image
+	frostFailed  bool
+)
+
+// makeFrost grows branching ice crystals out from the top left corner. Red
+// holds how far the frost must have grown to reach each pixel and green
+// how thick it is there.
+func makeFrost(r *rand.Rand) *ebiten.Image {
+	arrive := make([]float64, frostSize*frostSize)
+	thick := make([]float64, frostSize*frostSize)
+
+	// A thin haze that thins out away from the corner
+	for py := range frostSize {
+		for px := range frostSize {
+			d := math.Hypot(float64(px), float64(py)) / frostSize
+			arrive[py*frostSize+px] = min(d, 1)
+			thick[py*frostSize+px] = frostHaze * max(0, 1-d)
+		}
+	}
+
+	// Crystals that wander a little and fork off at sixty degrees, as ice does
+	var grow func(x, y, angle, length float64, depth int)
+	grow = func(x, y, angle, length float64, depth int) {
+		for i := 0.0; i < length; i++ {
+			angle += (r.Float64()*2 - 1) * 0.06
+			x += math.Cos(angle)
+			y += math.Sin(angle)
+			px, py := int(x), int(y)
+			if px < 0 || py < 0 || px >= frostSize || py >= frostSize {
+				return
+			}
+			j := py*frostSize + px
+			arrive[j] = min(arrive[j], math.Hypot(x, y)/frostSize*0.95)
+			thick[j] = max(thick[j], 1-float64(depth)*0.2)
+
+			if depth < frostDepth && r.Float64() < frostFork {
+				side := math.Pi / 3
+				if r.Intn(2) == 0 {
+					side = -side
+				}
+				grow(x, y, angle+side, (length-i)*0.4, depth+1)
+			}
+		}
+	}
+	for i := range frostBranches {
+		angle := (float64(i) + 0.5) / frostBranches * math.Pi / 2
+		grow(0, 0, angle, frostSize*(0.6+0.4*r.Float64()), 0)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, frostSize, frostSize))
+	for j := range arrive {
+		img.Pix[j*4+0] = uint8(arrive[j] * 255)
+		img.Pix[j*4+1] = uint8(thick[j] * 255)
+		img.Pix[j*4+3] = 255
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// updateFrost grows the frost while it is cold and snowing and melts it
+// back while the weather is clear or warm
+func (g *Game) updateFrost() {
+	if !g.config.Frost || frostFailed {
+		g.frost = 0
+		return
+	}
+	step := 1 / (g.config.FrostMinutes * 60 * float64(g.stepRate()))
+	if g.effect().cold && g.weather.level > frostWeather {
+		g.frost = min(g.frost+step, 1)
+	} else {
+		g.frost = max(g.frost-step*frostMelt, 0)
+	}
+}
+
+// drawFrost draws the frost creeping in from each corner of the screen
+func (g *Game) drawFrost(screen *ebiten.Image) {
+	if g.frost <= 0 || frostFailed {
+		return
+	}
+	if frostShader == nil {
+		shader, err := ebiten.NewShader(frostKage)
+		if err != nil {
+			log.Println("Could not compile the frost shader:", err)
+			frostFailed = true
+			return
+		}
+		frostShader = shader
+		frostPattern = makeFrost(g.rng)
+	}
+
+	w, h := float64(g.screenWidth), float64(g.screenHeight)
+	scale := min(w, h) * frostReach / frostSize
+	for _, corner := range [4][2]float64{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}} {
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images[0] = frostPattern
+		op.Uniforms = map[string]any{
+			"Level":   float32(g.frost),
+			"Opacity": float32(frostOpacity),
+		}
+		// Mirror the pattern into each corner
+		op.GeoM.Scale(corner[0]*scale, corner[1]*scale)
+		op.GeoM.Translate(max(0, -corner[0])*w, max(0, -corner[1])*h)
+		screen.DrawRectShader(frostSize, frostSize, frostShader, op)
+	}
+}
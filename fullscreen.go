@@ -0,0 +1,92 @@
+package main
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for detecting fullscreen applications
+const (
+	QUNS_BUSY                    = 2 // A fullscreen application is running
+	QUNS_RUNNING_D3D_FULL_SCREEN = 3 // A fullscreen Direct3D game is running
+	MONITOR_DEFAULTTOPRIMARY     = 1
+	MONITOR_DEFAULTTONEAREST     = 2
+)
+
+// monitorInfo mirrors the Windows MONITORINFO structure
+type monitorInfo struct {
+	cbSize    uint32
+	rcMonitor rect
+	rcWork    rect
+	dwFlags   uint32
+}
+
+// Window classes belonging to the shell rather than to a fullscreen application
+var shellClasses = map[string]bool{
+	"Progman":       true,
+	"WorkerW":       true,
+	"Shell_TrayWnd": true,
+}
+
+// WatchFullscreen pauses the game while another application is fullscreen
+// on the same monitor, checking once a second
+func WatchFullscreen(game *Game) {
+	ticker := time.NewTicker(1 * time.Second)
+	for range ticker.C {
+		busy := FullscreenAppActive()
+		game.Post(func(g *Game) {
+			if busy && g.config.PauseOnFullscreen {
+				g.Pause(pausedByFullscreen)
+			} else {
+				g.Resume(pausedByFullscreen)
+			}
+		})
+	}
+}
+
+// FullscreenAppActive reports whether a game, video or other application
+// is covering the monitor the snow is shown on
+func FullscreenAppActive() bool {
+	var state uintptr
+	if ret, _, _ := procSHQueryUserNotificationState.Call(uintptr(unsafe.Pointer(&state))); ret == 0 {
+		if state == QUNS_RUNNING_D3D_FULL_SCREEN {
+			return true
+		}
+		if state != QUNS_BUSY {
+			return false
+		}
+	}
+
+	// The shell only says some application is fullscreen, so check it is
+	// the foreground window and that it covers our monitor
+	fg, _, _ := procGetForegroundWindow.Call()
+	own := FindGameWindow()
+	if fg == 0 || fg == own || shellClasses[windowClass(fg)] {
+		return false
+	}
+
+	monitor, _, _ := procMonitorFromWindow.Call(fg, MONITOR_DEFAULTTONEAREST)
+	if own != 0 {
+		if ownMonitor, _, _ := procMonitorFromWindow.Call(own, MONITOR_DEFAULTTONEAREST); ownMonitor != monitor {
+			return false
+		}
+	}
+
+	var mi monitorInfo
+	mi.cbSize = uint32(unsafe.Sizeof(mi))
+	procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&mi)))
+
+	var r rect
+	procGetWindowRect.Call(fg, uintptr(unsafe.Pointer(&r)))
+	return r.left <= mi.rcMonitor.left && r.top <= mi.rcMonitor.top &&
+		r.right >= mi.rcMonitor.right && r.bottom >= mi.rcMonitor.bottom
+}
+
+// windowClass returns the class name of a window
+func windowClass(hwnd uintptr) string {
+	var buf [256]uint16
+	procGetClassName.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return windows.UTF16ToString(buf[:])
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"sort"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/sys/windows"
+)
+
+// How glyphs are drawn
+const (
+	glyphCell  = 64  // Height in pixels glyphs are rendered at
+	glyphScale = 4.0 // Glyphs are drawn this many times the flake size tall
+)
+
+// Glyphs drawn when none are configured, with their chances
+var defaultGlyphs = map[string]float64{"❄": 2, "❅": 1, "❆": 1}
+
+// Fonts glyphs are looked for in after glyph_font, starting with the
+// embedded Go font and falling back to the Windows symbol and emoji fonts
+var glyphFonts = []string{"Go", "Segoe UI Symbol", "Segoe UI Emoji"}
+
+// Constants for drawing text with GDI
+const (
+	FW_NORMAL                   = 400
+	DEFAULT_CHARSET             = 1
+	ANTIALIASED_QUALITY         = 4
+	DIB_RGB_COLORS              = 0
+	GGI_MARK_NONEXISTING_GLYPHS = 1
+	missingGlyph                = 0xffff
+)
+
+// bitmapInfoHeader mirrors the Windows BITMAPINFOHEADER structure
+type bitmapInfoHeader struct {
+	size          uint32
+	width         int32
+	height        int32
+	planes        uint16
+	bitCount      uint16
+	compression   uint32
+	sizeImage     uint32
+	xPelsPerMeter int32
+	yPelsPerMeter int32
+	clrUsed       uint32
+	clrImportant  uint32
+}
+
+// Whether the embedded Go font has been handed to GDI
+var goFontAdded bool
+
+// addGoFont makes the embedded Go font available to this process by name
+func addGoFont() {
+	if goFontAdded {
+		return
+	}
+	goFontAdded = true
+	var fonts uint32
+	if h, _, err := procAddFontMemResourceEx.Call(uintptr(unsafe.Pointer(&goregular.TTF[0])), uintptr(len(goregular.TTF)), 0, uintptr(unsafe.Pointer(&fonts))); h == 0 {
+		log.Println("Could not load the embedded font:", err)
+	}
+}
+
+// hasGlyphs reports whether every character of s is in the font selected into dc
+func hasGlyphs(dc uintptr, s []uint16) bool {
+	indices := make([]uint16, len(s))
+	if n, _, _ := procGetGlyphIndices.Call(dc, uintptr(unsafe.Pointer(&s[0])), uintptr(len(s)), uintptr(unsafe.Pointer(&indices[0])), GGI_MARK_NONEXISTING_GLYPHS); n == 0xffffffff {
+		return false
+	}
+	for _, i := range indices {
+		if i == missingGlyph {
+			return false
+		}
+	}
+	return true
+}
+
+// renderGlyph draws s in white on transparent with the first of fonts that
+// has all its characters, or the last of them if none does
+func renderGlyph(s string, fonts []string) (*ebiten.Image, error) {
+	text, err := windows.UTF16FromString(s)
+	if err != nil {
+		return nil, err
+	}
+	text = text[:len(text)-1] // Without the terminating zero
+
+	dc, _, _ := procCreateCompatibleDC.Call(0)
+	if dc == 0 {
+		return nil, fmt.Errorf("could not create a device context")
+	}
+	defer procDeleteDC.Call(dc)
+
+	var font uintptr
+	for i, name := range fonts {
+		face, _ := windows.UTF16PtrFromString(name)
+		// A negative height asks for the characters, not the cell, to be glyphCell tall
+		font, _, _ = procCreateFont.Call(^uintptr(glyphCell-1), 0, 0, 0, FW_NORMAL, 0, 0, 0, DEFAULT_CHARSET, 0, 0, ANTIALIASED_QUALITY, 0, uintptr(unsafe.Pointer(face)))
+		procSelectObject.Call(dc, font)
+		if hasGlyphs(dc, text) || i == len(fonts)-1 {
+			break
+		}
+		procDeleteObject.Call(font)
+	}
+	defer procDeleteObject.Call(font)
+
+	var size struct{ cx, cy int32 }
+	procGetTextExtentPoint32.Call(dc, uintptr(unsafe.Pointer(&text[0])), uintptr(len(text)), uintptr(unsafe.Pointer(&size)))
+	if size.cx <= 0 || size.cy <= 0 {
+		return nil, fmt.Errorf("glyph %q has no size", s)
+	}
+
+	// White text on black in a bitmap whose pixels can be read back
+	header := bitmapInfoHeader{width: size.cx, height: -size.cy, planes: 1, bitCount: 32}
+	header.size = uint32(unsafe.Sizeof(header))
+	var bits unsafe.Pointer
+	bitmap, _, _ := procCreateDIBSection.Call(dc, uintptr(unsafe.Pointer(&header)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(&bits)), 0, 0)
+	if bitmap == 0 {
+		return nil, fmt.Errorf("could not create a bitmap for glyph %q", s)
+	}
+	defer procDeleteObject.Call(bitmap)
+	procSelectObject.Call(dc, bitmap)
+	procSetTextColor.Call(dc, 0xffffff)
+	procSetBkColor.Call(dc, 0)
+	procTextOut.Call(dc, 0, 0, uintptr(unsafe.Pointer(&text[0])), uintptr(len(text)))
+
+	// Take the brightness of each pixel as its opacity
+	pixels := unsafe.Slice((*byte)(bits), int(size.cx*size.cy*4))
+	img := image.NewNRGBA(image.Rect(0, 0, int(size.cx), int(size.cy)))
+	for i := 0; i < len(pixels); i += 4 {
+		a := max(pixels[i], pixels[i+1], pixels[i+2])
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 255, 255, 255, a
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
+
+// LoadGlyphs renders each of the glyphs, with its chance of being picked
+func LoadGlyphs(glyphs map[string]float64, font string) ([]customSprite, error) {
+	if len(glyphs) == 0 {
+		glyphs = defaultGlyphs
+	}
+	addGoFont()
+	fonts := glyphFonts
+	if font != "" {
+		fonts = append([]string{font}, fonts...)
+	}
+
+	// In a fixed order, so a seeded snowfall picks the same glyphs each run
+	names := make([]string, 0, len(glyphs))
+	for s := range glyphs {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	var sprites []customSprite
+	for _, s := range names {
+		img, err := renderGlyph(s, fonts)
+		if err != nil {
+			return nil, err
+		}
+		sprites = append(sprites, customSprite{image: img, weight: glyphs[s], scaleMin: 1, scaleMax: 1})
+	}
+	return sprites, nil
+}
+
+// validateGlyphs checks the glyphs setting
+func validateGlyphs(glyphs map[string]float64) error {
+	for s, weight := range glyphs {
+		switch {
+		case s == "":
+			return fmt.Errorf("glyphs: a glyph must not be empty")
+		case weight <= 0:
+			return fmt.Errorf("glyphs: chance of %q must be positive, got %g", s, weight)
+		}
+	}
+	return nil
+}
+
+// drawGlyph draws a flake as its glyph, turned to the flake's angle and tinted with c
+func (g *Game) drawGlyph(screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	img := g.customSprites[f.design%len(g.customSprites)].image
+	bounds := img.Bounds()
+	scale := f.size * glyphScale / glyphCell
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(bounds.Dx())/2, -float64(bounds.Dy())/2)
+	op.GeoM.Rotate(f.angle)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(f.x, f.y)
+	op.ColorScale.ScaleWithColor(c)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(img, op)
+}
@@ -2,7 +2,13 @@ module github.com/nealhardesty/winsnow
 
 go 1.24.0
 
-require github.com/hajimehoshi/ebiten/v2 v2.8.7
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/hajimehoshi/ebiten/v2 v2.8.7
+	golang.org/x/image v0.20.0
+	golang.org/x/sys v0.31.0
+)
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240911145611-4856209ac325 // indirect
@@ -10,5 +16,4 @@ require (
 	github.com/ebitengine/purego v0.8.0 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/sys v0.31.0 // indirect
 )
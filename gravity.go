@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The flakes always fall straight down the frame they move in. When
+// gravity is set to pull some other way, the frame is turned to match as
+// it is drawn, and made big enough to still cover the whole screen.
+
+// fallsDown reports whether gravity pulls straight down the screen, so the
+// flakes' frame is the screen itself
+func (c *Config) fallsDown() bool {
+	return c.GravityX == 0 && c.GravityY > 0
+}
+
+// fallTurn returns the cosine and sine of the angle the flakes' frame is
+// turned by on screen
+func (c *Config) fallTurn() (float64, float64) {
+	l := math.Hypot(c.GravityX, c.GravityY)
+	return c.GravityY / l, -c.GravityX / l
+}
+
+// fallSize returns the width and height of the frame the flakes fall in
+func (g *Game) fallSize() (float64, float64) {
+	w, h := float64(g.screenWidth), float64(g.screenHeight)
+	if g.config.fallsDown() {
+		return w, h
+	}
+	cos, sin := g.config.fallTurn()
+	return math.Abs(w*cos) + math.Abs(h*sin), math.Abs(w*sin) + math.Abs(h*cos)
+}
+
+// fallGeoM returns the transform from the flakes' frame to the screen
+func (g *Game) fallGeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	if g.config.fallsDown() {
+		return m
+	}
+	w, h := g.fallSize()
+	cos, sin := g.config.fallTurn()
+	m.Translate(-w/2, -h/2)
+	m.Rotate(math.Atan2(sin, cos))
+	m.Translate(float64(g.screenWidth)/2, float64(g.screenHeight)/2)
+	return m
+}
+
+// toFall converts a point on screen to the flakes' frame
+func (g *Game) toFall(x, y float64) (float64, float64) {
+	m := g.fallGeoM()
+	m.Invert()
+	return m.Apply(x, y)
+}
+
+// fallToScreen converts a point and a velocity in the flakes' frame to the screen
+func (g *Game) fallToScreen(x, y, vx, vy float64) (float64, float64, float64, float64) {
+	if g.config.fallsDown() {
+		return x, y, vx, vy
+	}
+	cos, sin := g.config.fallTurn()
+	m := g.fallGeoM()
+	x, y = m.Apply(x, y)
+	return x, y, vx*cos - vy*sin, vx*sin + vy*cos
+}
+
+// fallCursor returns the mouse pointer as seen from the flakes' frame
+func (g *Game) fallCursor() Cursor {
+	c := g.cursor
+	if g.config.fallsDown() {
+		return c
+	}
+	cos, sin := g.config.fallTurn()
+	c.x, c.y = g.toFall(c.x, c.y)
+	c.vx, c.vy = c.vx*cos+c.vy*sin, c.vy*cos-c.vx*sin
+	return c
+}
+
+// Image the flakes are drawn on before it is turned onto the screen, when
+// gravity doesn't pull straight down
+var fallLayer *ebiten.Image
+
+// fallTarget returns the image to draw the flakes on: the screen itself,
+// or a cleared layer for finishFall to turn onto it
+func (g *Game) fallTarget(screen *ebiten.Image) *ebiten.Image {
+	if g.config.fallsDown() {
+		return screen
+	}
+	w, h := g.fallSize()
+	width, height := int(math.Ceil(w)), int(math.Ceil(h))
+	if fallLayer == nil || fallLayer.Bounds().Dx() != width || fallLayer.Bounds().Dy() != height {
+		if fallLayer != nil {
+			fallLayer.Deallocate()
+		}
+		fallLayer = ebiten.NewImage(width, height)
+	}
+	fallLayer.Clear()
+	return fallLayer
+}
+
+// finishFall turns the layer the flakes were drawn on onto the screen
+func (g *Game) finishFall(screen, layer *ebiten.Image) {
+	if layer == screen {
+		return
+	}
+	op := &ebiten.DrawImageOptions{GeoM: g.fallGeoM()}
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(layer, op)
+}
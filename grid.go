@@ -0,0 +1,76 @@
+package main
+
+// Size of the cells the screen is split into for finding nearby flakes
+const gridCell = 32.0 // Width and height of a cell in pixels
+
+// Grid buckets the flakes and piles by where they are on screen, so
+// clumping, the mouse pointer and landing only look at those nearby
+// instead of every one. It is rebuilt from scratch each time it is used,
+// reusing the buckets from last time.
+type Grid struct {
+	cols, rows int
+	cells      [][]int   // Indices of the flakes in each cell, row by row
+	columns    [][]*Pile // Piles lying across each column of cells
+}
+
+// cell returns the column and row of the cell x, y is in, taking points
+// off the screen to the nearest cell on it
+func (gr *Grid) cell(x, y float64) (int, int) {
+	col := min(max(int(x/gridCell), 0), gr.cols-1)
+	row := min(max(int(y/gridCell), 0), gr.rows-1)
+	return col, row
+}
+
+// index buckets the flakes where they are now in the frame they fall in,
+// and the piles if they can catch them
+func (g *Game) index() {
+	gr := &g.grid
+	w, h := g.fallSize()
+	gr.cols = int(w/gridCell) + 1
+	gr.rows = int(h/gridCell) + 1
+	if len(gr.cells) != gr.cols*gr.rows || len(gr.columns) != gr.cols {
+		gr.cells = make([][]int, gr.cols*gr.rows)
+		gr.columns = make([][]*Pile, gr.cols)
+	}
+	for i := range gr.cells {
+		gr.cells[i] = gr.cells[i][:0]
+	}
+	for i := range gr.columns {
+		gr.columns[i] = gr.columns[i][:0]
+	}
+
+	for i, f := range g.snowflakes {
+		col, row := gr.cell(f.x, f.y)
+		gr.cells[row*gr.cols+col] = append(gr.cells[row*gr.cols+col], i)
+	}
+	if !g.config.fallsDown() {
+		return
+	}
+	for _, p := range g.piles {
+		from, _ := gr.cell(p.x, 0)
+		to, _ := gr.cell(p.x+p.width(), 0)
+		for col := from; col <= to; col++ {
+			gr.columns[col] = append(gr.columns[col], p)
+		}
+	}
+}
+
+// Near calls visit with the index of each flake that may be within radius
+// of x, y. Flakes a little further away may be visited too.
+func (gr *Grid) Near(x, y, radius float64, visit func(i int)) {
+	left, top := gr.cell(x-radius, y-radius)
+	right, bottom := gr.cell(x+radius, y+radius)
+	for row := top; row <= bottom; row++ {
+		for col := left; col <= right; col++ {
+			for _, i := range gr.cells[row*gr.cols+col] {
+				visit(i)
+			}
+		}
+	}
+}
+
+// PilesAt returns the piles that may lie across the screen at x
+func (gr *Grid) PilesAt(x float64) []*Pile {
+	col, _ := gr.cell(x, 0)
+	return gr.columns[col]
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// newGridGame returns a game on a screen of the given size with a flake at
+// each of the points and a pile along the whole bottom edge
+func newGridGame(width, height int, points ...[2]float64) *Game {
+	g := &Game{config: DefaultConfig(), screenWidth: width, screenHeight: height}
+	for _, p := range points {
+		g.snowflakes = append(g.snowflakes, Snowflake{x: p[0], y: p[1]})
+	}
+	g.piles = map[uintptr]*Pile{groundPile: NewPile(surfaceGround, 0, float64(height), float64(width))}
+	return g
+}
+
+func TestGridSurvivesRotation(t *testing.T) {
+	g := newGridGame(1920, 1080)
+	g.index()
+
+	// Swapping the sides keeps the number of cells but not of columns
+	g.screenWidth, g.screenHeight = 1080, 1920
+	g.piles = newGridGame(1080, 1920).piles
+	g.index()
+	if got, want := len(g.grid.columns), g.grid.cols; got != want {
+		t.Fatalf("got %d columns, want %d", got, want)
+	}
+
+	g.screenWidth, g.screenHeight = 1920, 1080
+	g.piles = newGridGame(1920, 1080).piles
+	g.index()
+	if piles := g.grid.PilesAt(1900); len(piles) != 1 {
+		t.Fatalf("got %d piles at the right edge, want 1", len(piles))
+	}
+}
+
+func TestGridNear(t *testing.T) {
+	g := newGridGame(640, 480, [2]float64{100, 100}, [2]float64{110, 105}, [2]float64{500, 400})
+	g.index()
+
+	var found []int
+	g.grid.Near(105, 100, 20, func(i int) { found = append(found, i) })
+	slices.Sort(found)
+	if !slices.Equal(found, []int{0, 1}) {
+		t.Fatalf("got flakes %v near the first two, want [0 1]", found)
+	}
+}
+
+func TestGridPilesAt(t *testing.T) {
+	g := newGridGame(640, 480)
+	g.index()
+	for _, x := range []float64{-10, 0, 320, 639, 700} {
+		if piles := g.grid.PilesAt(x); len(piles) != 1 {
+			t.Errorf("got %d piles at x=%g, want 1", len(piles), x)
+		}
+	}
+
+	// Snow falling sideways lands on nothing
+	g.config.GravityX, g.config.GravityY = 1, 0
+	g.index()
+	if piles := g.grid.PilesAt(320); len(piles) != 0 {
+		t.Errorf("got %d piles with sideways gravity, want 0", len(piles))
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"math"
+	"time"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How sleet and hail bounce
+const (
+	sleetSpeed    = 2.0 // Times faster than snow sleet falls
+	sleetBounce   = 0.3 // Share of its speed an ice pellet keeps as it bounces
+	hailSpeed     = 2.5 // Times faster than snow hail falls
+	hailBounce    = 0.5 // Share of its speed a hailstone keeps as it bounces
+	hailScale     = 2.0 // Hailstones are drawn this many times the flake size
+	bounceScatter = 1.0 // Fastest a bouncing particle is knocked sideways, in pixels per frame
+)
+
+// drawSleet draws a mix of raindrops and small ice pellets
+func drawSleet(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	if f.design%2 == 0 {
+		drawRaindrop(g, screen, f, c)
+		return
+	}
+	g.drawDot(screen, f, c)
+}
+
+// sleetImpact splashes the raindrops and bounces the ice pellets
+func sleetImpact(g *Game, f *Snowflake) {
+	if f.design%2 == 0 {
+		splash(g, f)
+		return
+	}
+	g.bounce(f, f.size, sleetBounce)
+}
+
+// drawHailstone draws a hailstone as a large, faintly shaded ball
+func drawHailstone(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	r := float32(f.size * hailScale / 2)
+	vector.DrawFilledCircle(screen, float32(f.x), float32(f.y), r, c, true)
+	c.A /= 2
+	vector.StrokeCircle(screen, float32(f.x), float32(f.y), r, 1, color.NRGBA{255, 255, 255, c.A}, true)
+}
+
+// hailImpact bounces a hailstone and, if turned on, plays a tap
+func hailImpact(g *Game, f *Snowflake) {
+	g.bounce(f, f.size*hailScale, hailBounce)
+	if g.config.HailSound {
+		playTap()
+	}
+}
+
+// bounce throws a copy of the particle back up from where it landed,
+// which disappears when it comes down again
+func (g *Game) bounce(f *Snowflake, size, keep float64) {
+	g.emitDebris(Snowflake{
+		x:     f.x,
+		y:     f.y - 1,
+		size:  size,
+		speed: f.speed,
+		vx:    f.vx*keep + (g.rng.Float64()*2-1)*bounceScatter,
+		vy:    -f.vy * keep,
+	})
+}
+
+// Constants for PlaySound
+const (
+	SND_ASYNC     = 0x0001
+	SND_NODEFAULT = 0x0002
+	SND_MEMORY    = 0x0004
+)
+
+// Shape of the tap played when a hailstone lands
+const (
+	tapRate     = 22050                 // Samples per second
+	tapLength   = 30 * time.Millisecond // How long the tap rings
+	tapPitch    = 2400.0                // Frequency of the tap in hertz
+	tapInterval = 40 * time.Millisecond // Shortest time between taps, so a storm doesn't become a drone
+)
+
+// The tap as a WAV file, and when it was last played
+var (
+	tapSound  = makeTap()
+	lastTapAt time.Time
+)
+
+// makeTap synthesizes a short, quickly fading click as a 16-bit mono WAV file
+func makeTap() []byte {
+	n := int(tapLength.Seconds() * tapRate)
+	samples := make([]int16, n)
+	for i := range samples {
+		t := float64(i) / tapRate
+		fade := math.Exp(-t * 200)
+		samples[i] = int16(math.Sin(2*math.Pi*tapPitch*t) * fade * 8000)
+	}
+
+	var buf bytes.Buffer
+	dataSize := uint32(n * 2)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16),          // Size of the format chunk
+		uint16(1),           // PCM
+		uint16(1),           // Mono
+		uint32(tapRate),     // Sample rate
+		uint32(tapRate * 2), // Bytes per second
+		uint16(2),           // Bytes per sample
+		uint16(16),          // Bits per sample
+	} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+// playTap plays the hail tap without waiting for it, unless one was just played
+func playTap() {
+	if time.Since(lastTapAt) < tapInterval {
+		return
+	}
+	lastTapAt = time.Now()
+	procPlaySound.Call(uintptr(unsafe.Pointer(&tapSound[0])), 0, SND_MEMORY|SND_ASYNC|SND_NODEFAULT)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// Modifier flags for RegisterHotKey
+const (
+	MOD_ALT      = 0x0001
+	MOD_CONTROL  = 0x0002
+	MOD_SHIFT    = 0x0004
+	MOD_WIN      = 0x0008
+	MOD_NOREPEAT = 0x4000
+)
+
+// Virtual key codes for the named keys a hotkey can use
+var virtualKeys = map[string]uint32{
+	"space":    0x20,
+	"pageup":   0x21,
+	"pagedown": 0x22,
+	"end":      0x23,
+	"home":     0x24,
+	"left":     0x25,
+	"up":       0x26,
+	"right":    0x27,
+	"down":     0x28,
+	"plus":     0xBB,
+	"minus":    0xBD,
+}
+
+// hotkeyActions are the things a hotkey can do, keyed by the name used in the config
+var hotkeyActions = map[string]func(*Game){
+	"more_flakes": func(g *Game) {
+		g.Adjust(func(c *Config) {
+			if c.densityFollows() || c.Density == 0 {
+				c.SetFlakes(max(c.Flakes*5/4, c.Flakes+10))
+			} else {
+				c.Density *= 1.25
+			}
+		})
+	},
+	"fewer_flakes": func(g *Game) {
+		g.Adjust(func(c *Config) {
+			if c.densityFollows() || c.Density == 0 {
+				c.SetFlakes(c.Flakes * 4 / 5)
+			} else {
+				c.Density *= 0.8
+			}
+		})
+	},
+	"wind_left": func(g *Game) {
+		g.Adjust(func(c *Config) { c.WindBias -= 0.25 })
+	},
+	"wind_right": func(g *Game) {
+		g.Adjust(func(c *Config) { c.WindBias += 0.25 })
+	},
+	"pause": func(g *Game) {
+		if g.paused&pausedByUser != 0 {
+			g.Resume(pausedByUser)
+		} else {
+			g.Pause(pausedByUser)
+		}
+	},
+	"settings": func(g *Game) {
+		g.settings.toggle(g)
+	},
+	"blizzard": func(g *Game) {
+		g.StartBlizzard()
+	},
+	"fireworks": func(g *Game) {
+		g.StartFireworks()
+	},
+	"confetti": func(g *Game) {
+		g.FireConfetti()
+	},
+	"sleigh": func(g *Game) {
+		g.FlySleigh()
+	},
+	"plow": func(g *Game) {
+		g.SendPlow()
+	},
+	"hud": func(g *Game) {
+		g.Adjust(func(c *Config) { c.HUD = !c.HUD })
+	},
+}
+
+// DefaultHotkeys returns the key combination for each hotkey action bound
+// by default, which are only those on the arrow keys. Ctrl+Alt is AltGr on
+// many keyboard layouts, so Ctrl+Alt with a letter would stop that letter's
+// AltGr character being typed. The rest can be bound in the [hotkeys] section.
+func DefaultHotkeys() map[string]string {
+	return map[string]string{
+		"more_flakes":  "Ctrl+Alt+Up",
+		"fewer_flakes": "Ctrl+Alt+Down",
+		"wind_left":    "Ctrl+Alt+Left",
+		"wind_right":   "Ctrl+Alt+Right",
+	}
+}
+
+// parseHotkey turns a combination like "Ctrl+Alt+Up" into RegisterHotKey arguments
+func parseHotkey(combo string) (mods, vk uint32, err error) {
+	parts := strings.Split(strings.ToLower(combo), "+")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			switch part {
+			case "ctrl", "control":
+				mods |= MOD_CONTROL
+			case "alt":
+				mods |= MOD_ALT
+			case "shift":
+				mods |= MOD_SHIFT
+			case "win":
+				mods |= MOD_WIN
+			default:
+				return 0, 0, fmt.Errorf("hotkey %q: unknown modifier %q", combo, part)
+			}
+			continue
+		}
+
+		// The last part is the key itself
+		switch {
+		case len(part) == 1 && (part[0] >= 'a' && part[0] <= 'z' || part[0] >= '0' && part[0] <= '9'):
+			vk = uint32(strings.ToUpper(part)[0])
+		case len(part) >= 2 && part[0] == 'f':
+			var n uint32
+			if _, err := fmt.Sscanf(part[1:], "%d", &n); err != nil || n < 1 || n > 24 {
+				return 0, 0, fmt.Errorf("hotkey %q: unknown key %q", combo, part)
+			}
+			vk = 0x70 + n - 1
+		default:
+			var ok bool
+			if vk, ok = virtualKeys[part]; !ok {
+				return 0, 0, fmt.Errorf("hotkey %q: unknown key %q", combo, part)
+			}
+		}
+	}
+	return mods | MOD_NOREPEAT, vk, nil
+}
+
+// ListenHotkeys registers the configured global hotkeys and runs their
+// actions on the game. It blocks running the thread's message loop, so
+// call it on its own goroutine. Hotkeys are read once at startup.
+func ListenHotkeys(game *Game, hotkeys map[string]string) {
+	// Hotkey messages go to the thread that registered them
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Register in a fixed order so hotkey IDs are stable
+	var names []string
+	for name := range hotkeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var actions []func(*Game)
+	for _, name := range names {
+		action, ok := hotkeyActions[name]
+		if !ok {
+			log.Printf("Unknown hotkey action %q", name)
+			continue
+		}
+		mods, vk, err := parseHotkey(hotkeys[name])
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		id := len(actions) + 1
+		if ok, _, err := procRegisterHotKey.Call(0, uintptr(id), uintptr(mods), uintptr(vk)); ok == 0 {
+			log.Printf("Could not register hotkey %s for %s: %v", hotkeys[name], name, err)
+			continue
+		}
+		actions = append(actions, action)
+	}
+	if len(actions) == 0 {
+		return
+	}
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		if m.message == WM_HOTKEY && m.wParam >= 1 && int(m.wParam) <= len(actions) {
+			game.Post(actions[m.wParam-1])
+		}
+	}
+}
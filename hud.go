@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Layout of the conditions shown in the corner
+const (
+	hudWidth      = 220 // Width of the panel in pixels
+	hudMargin     = 16  // Gap between the panel and the edges of the screen
+	hudPadding    = 8   // Gap between the edge of the panel and its text
+	hudLineHeight = 16  // Height of each line of text
+)
+
+// hudLines describes the current conditions, one per line
+func (g *Game) hudLines() []string {
+	intensity := "custom"
+	if g.config.Intensity != noIntensity {
+		intensity = fmt.Sprint(g.config.Intensity)
+	}
+	arrow := "-"
+	if g.wind > 0.05 {
+		arrow = ">"
+	} else if g.wind < -0.05 {
+		arrow = "<"
+	}
+
+	lines := []string{
+		"Effect:    " + g.config.Effect,
+		"Intensity: " + intensity,
+		fmt.Sprintf("Wind:      %s %.2f", arrow, math.Abs(g.wind)),
+	}
+	if g.config.Weather.Cycle {
+		lines = append(lines, fmt.Sprintf("Weather:   %s %.0f%%", stageNames[g.weather.stage], g.weather.level*100))
+	}
+	if g.quality != qualityFull {
+		lines = append(lines, "Quality:   "+qualityNames[g.quality])
+	}
+	if g.blizzard.level > 0 {
+		lines = append(lines, fmt.Sprintf("Blizzard:  %.0f%%", g.blizzard.level*100))
+	}
+	return append(lines,
+		fmt.Sprintf("Particles: %d", len(g.snowflakes)+g.particles.Len()+g.debris.Len()),
+		fmt.Sprintf("FPS:       %.0f", ebiten.ActualFPS()),
+	)
+}
+
+// drawHUD shows the current conditions in the top right corner of the screen
+func (g *Game) drawHUD(screen *ebiten.Image) {
+	if !g.config.HUD {
+		return
+	}
+	lines := g.hudLines()
+	x := g.screenWidth - hudWidth - hudMargin
+	height := hudPadding*2 + len(lines)*hudLineHeight
+	vector.DrawFilledRect(screen, float32(x), hudMargin, hudWidth, float32(height), overlay.panel, false)
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, x+hudPadding, hudMargin+hudPadding+i*hudLineHeight)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How icicles grow, drip and break
+const (
+	icicleSpacing  = 14                  // Pixels between places an icicle can hang
+	icicleShare    = 0.6                 // Share of those places an icicle grows in
+	icicleLenMin   = 8.0                 // Shortest an icicle grows before it may break, in pixels
+	icicleLenMax   = 60.0                // Longest an icicle grows, in pixels
+	icicleGrowth   = 0.0025              // Pixels an icicle grows each frame
+	icicleWidthMin = 2.0                 // Thinnest an icicle is at its root, in pixels
+	icicleWidthMax = 5.0                 // Thickest an icicle is at its root, in pixels
+	icicleDripMin  = 300                 // Fewest frames between drips
+	icicleDripMax  = 1500                // Most frames between drips
+	icicleBreak    = 1.0 / (60 * 60 * 5) // Chance each frame a full-grown icicle breaks off
+	icicleChunk    = 5.0                 // Pixels of icicle in each falling fragment
+)
+
+// Colors of the ice and the glint down its middle
+var (
+	icicleColor = color.NRGBA{0xd6, 0xec, 0xff, 0xc0}
+	icicleGlint = color.NRGBA{0xff, 0xff, 0xff, 0xe0}
+)
+
+// Keys of the monitor tops among the surfaces, counting down from the top
+// of the range so they can't clash with window handles
+func monitorSurface(index int) uintptr {
+	return ^uintptr(index)
+}
+
+// icicle is one spike of ice hanging from an eave
+type icicle struct {
+	at     float64 // Offset along the eave
+	length float64 // How far it hangs down in pixels
+	full   float64 // Length it grows to
+	width  float64 // Width at the root in pixels
+	drip   int     // Frames until the next drop falls from its tip
+}
+
+// Eave is an edge icicles hang from, such as the top of a monitor
+type Eave struct {
+	x, y    float64
+	width   float64
+	icicles []icicle
+}
+
+// NewEave lines the edge from x to x+width at height y with icicles that
+// have just started to grow
+func NewEave(x, y, width float64, r *rand.Rand) *Eave {
+	e := &Eave{x: x, y: y, width: width}
+	for at := icicleSpacing / 2.0; at < width; at += icicleSpacing {
+		if r.Float64() >= icicleShare {
+			continue
+		}
+		e.icicles = append(e.icicles, icicle{
+			at:    at + (r.Float64()*2-1)*icicleSpacing/3,
+			full:  icicleLenMin + r.Float64()*(icicleLenMax-icicleLenMin),
+			width: icicleWidthMin + r.Float64()*(icicleWidthMax-icicleWidthMin),
+			drip:  icicleDripMin + r.Intn(icicleDripMax-icicleDripMin),
+		})
+	}
+	return e
+}
+
+// iciclesOn reports whether icicles should hang from a kind of surface
+func (g *Game) iciclesOn(kind surfaceKind) bool {
+	switch kind {
+	case surfaceMonitor:
+		return g.config.Icicles
+	case surfaceWindow:
+		return g.config.Icicles && g.config.IciclesOnWindows
+	}
+	return false
+}
+
+// hang keeps the eave of a surface where it is, breaking its icicles off
+// if the surface has moved
+func (g *Game) hang(id uintptr, kind surfaceKind, x, y, width float64) {
+	e, ok := g.eaves[id]
+	if !g.iciclesOn(kind) {
+		delete(g.eaves, id)
+		return
+	}
+	if ok {
+		if e.x == x && e.y == y && e.width == width {
+			return
+		}
+		for i := range e.icicles {
+			g.snap(e, &e.icicles[i])
+		}
+	}
+	g.eaves[id] = NewEave(x, y, width, g.rng)
+}
+
+// updateIcicles grows the icicles, lets drops fall from their tips and now
+// and then breaks off one that has grown as long as it will
+func (g *Game) updateIcicles(r *rand.Rand) {
+	for _, e := range g.eaves {
+		for i := range e.icicles {
+			c := &e.icicles[i]
+			c.length = min(c.length+icicleGrowth, c.full)
+
+			if c.drip--; c.drip <= 0 {
+				c.drip = icicleDripMin + r.Intn(icicleDripMax-icicleDripMin)
+				if c.length > icicleLenMin/2 {
+					x, y := g.toFall(e.x+c.at, e.y+c.length)
+					g.emitDebris(Snowflake{
+						x:     x,
+						y:     y,
+						size:  1,
+						speed: g.config.SpeedMax,
+					})
+				}
+			}
+
+			if c.length >= c.full && r.Float64() < icicleBreak {
+				g.snap(e, c)
+				c.full = icicleLenMin + r.Float64()*(icicleLenMax-icicleLenMin)
+			}
+		}
+	}
+}
+
+// snap breaks an icicle off, dropping it as a few fragments of ice
+func (g *Game) snap(e *Eave, c *icicle) {
+	for y := 0.0; y < c.length; y += icicleChunk {
+		// Thinner toward the tip
+		taper := 1 - y/c.length
+		fx, fy := g.toFall(e.x+c.at, e.y+y)
+		g.emitDebris(Snowflake{
+			x:     fx,
+			y:     fy,
+			size:  max(1, c.width*taper),
+			speed: g.config.SpeedMax,
+			vx:    (g.rng.Float64()*2 - 1) * 0.5,
+		})
+	}
+	c.length = 0
+}
+
+// drawIcicles draws the icicles as spikes of ice tapering to a point
+func (g *Game) drawIcicles(screen *ebiten.Image) {
+	for _, e := range g.eaves {
+		for _, c := range e.icicles {
+			if c.length < 1 {
+				continue
+			}
+			x := float32(e.x + c.at)
+			top := float32(e.y)
+			const steps = 4
+			for s := range steps {
+				y0 := top + float32(c.length)*float32(s)/steps
+				y1 := top + float32(c.length)*float32(s+1)/steps
+				w := float32(c.width) * float32(steps-s) / steps
+				vector.StrokeLine(screen, x, y0, x, y1, w, icicleColor, true)
+			}
+			vector.StrokeLine(screen, x-float32(c.width)/6, top, x, top+float32(c.length)*0.7, 0.8, icicleGlint, true)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Intensity used when the config does not set one; the individual settings apply as-is
+const noIntensity = -1
+
+// ApplyIntensity maps a single 0-100 knob onto flake count, speed, wind and
+// opacity together. The curves keep low settings sparse and gentle while
+// the top end gets dense quickly.
+func (c *Config) ApplyIntensity(intensity int) error {
+	if intensity < 0 || intensity > 100 {
+		return fmt.Errorf("intensity must be between 0 and 100, got %d", intensity)
+	}
+
+	t := float64(intensity) / 100
+	c.Intensity = intensity
+	c.SetFlakes(int(math.Round(2000 * t * t)))
+	c.SpeedMin = 2 + 10*t
+	c.SpeedMax = 5 + 20*t
+	c.Wind = 0.2 + 3.8*math.Pow(t, 1.5)
+	c.Opacity = 0.5 + 0.5*math.Sqrt(t)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// Pipe the running instance listens on for commands from later
+// invocations like "winsnow pause"
+const ipcPipe = "ipc"
+
+// How long a client waits for the running instance to answer
+const ipcTimeout = 2 * time.Second
+
+// ServeIPC answers commands from other winsnow invocations by the same
+// user. It returns an error if the pipe is taken, which usually means
+// another instance is already running.
+func ServeIPC(game *Game) error {
+	ln, err := ListenPipe(ipcPipe)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				log.Println("IPC listener stopped:", err)
+				return
+			}
+			go handleIPC(game, conn)
+		}
+	}()
+	return nil
+}
+
+// handleIPC reads a single command from conn and writes back the reply
+func handleIPC(game *Game, conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	// Run the command on the game goroutine and wait for its answer
+	reply := make(chan string, 1)
+	game.Post(func(g *Game) { reply <- g.RunCommand(strings.TrimSpace(line)) })
+
+	select {
+	case r := <-reply:
+		fmt.Fprintln(conn, r)
+	case <-time.After(ipcTimeout):
+		fmt.Fprintln(conn, "error: no response")
+	}
+}
+
+// SendCommand sends cmd to the running instance and returns its reply
+func SendCommand(cmd string) (string, error) {
+	return sendTo(ipcPipe, "winsnow", cmd)
+}
+
+// SendServiceCommand sends cmd to the running supervisor and returns its reply
+func SendServiceCommand(cmd string) (string, error) {
+	return sendTo(servicePipe, "the winsnow service", cmd)
+}
+
+// sendTo sends cmd to the process named name listening on the pipe of the
+// given kind and returns its reply
+func sendTo(kind, name, cmd string) (string, error) {
+	conn, err := DialPipe(kind, ipcTimeout)
+	if err != nil {
+		return "", fmt.Errorf("%s is not running", name)
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return "", err
+	}
+
+	// Pipes have no deadlines, so give up waiting on the reply instead
+	type answer struct {
+		reply string
+		err   error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		done <- answer{reply, err}
+	}()
+	select {
+	case a := <-done:
+		if a.err != nil {
+			return "", a.err
+		}
+		return strings.TrimSpace(a.reply), nil
+	case <-time.After(ipcTimeout * 2):
+		return "", fmt.Errorf("%s did not answer", name)
+	}
+}
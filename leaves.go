@@ -0,0 +1,109 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How leaves look and behave
+const (
+	leafSpeed = 0.35 // Times as fast as snow leaves fall
+	leafSway  = 6.0  // Times as far as snow leaves rock from side to side
+	leafScale = 5.0  // Leaves are drawn this many times the flake size
+	leafRest  = 180  // Frames a leaf lies where it landed before fading away
+	leafFade  = 60   // Frames over which a resting leaf fades out
+)
+
+// Autumn colors the leaves are picked from
+var autumnPalette = []color.NRGBA{
+	{0xc0, 0x39, 0x2b, 0xff}, // Red
+	{0xd3, 0x54, 0x00, 0xff}, // Rust
+	{0xe6, 0x7e, 0x22, 0xff}, // Orange
+	{0xf1, 0xc4, 0x0f, 0xff}, // Yellow
+	{0x8e, 0x5a, 0x2b, 0xff}, // Brown
+}
+
+// The leaf shape, white on transparent, made on first use
+var leafSprite *ebiten.Image
+
+// Leaves that have landed lie still in the debris, fading away at the end
+var restingLeafBehavior = &Behavior{drag: 1, draw: drawRestingLeaf}
+
+// makeLeaf draws a pointed oval leaf with a stem and a paler middle vein
+func makeLeaf() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, spriteCell, spriteCell))
+	half := float64(spriteCell) / 2
+	for py := 0; py < spriteCell; py++ {
+		for px := 0; px < spriteCell; px++ {
+			// Along the leaf from -1 at the stem to 1 at the tip, and across it
+			u := (float64(px) + 0.5 - half) / (half - 2)
+			v := (float64(py) + 0.5 - half) / (half - 2)
+			width := 0.45 * math.Sin(math.Pi*(u+1)/2) * (1.1 - 0.2*u)
+
+			a := uint8(0)
+			switch {
+			case math.Abs(u) <= 1 && math.Abs(v) < width && math.Abs(v) < 0.04:
+				a = 170 // Vein
+			case math.Abs(u) <= 1 && math.Abs(v) < width:
+				a = 255
+			case u < -0.95 && u > -1.25 && math.Abs(v) < 0.05:
+				a = 255 // Stem
+			}
+			img.SetNRGBA(px, py, color.NRGBA{255, 255, 255, a})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// drawLeaf draws a leaf in its own autumn color, tumbling end over end and
+// fluttering as it turns edge-on and back
+func drawLeaf(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	if leafSprite == nil {
+		leafSprite = makeLeaf()
+	}
+	scale := f.size * leafScale / spriteCell
+	tint := autumnPalette[f.design%len(autumnPalette)]
+	drawTumbling(screen, leafSprite, f, scale, math.Cos(f.phase*2), color.NRGBA{tint.R, tint.G, tint.B, c.A})
+}
+
+// drawRestingLeaf draws a leaf lying flat where it landed, fading away at the end
+func drawRestingLeaf(g *Game, screen *ebiten.Image, p Particle) {
+	if leafSprite == nil {
+		leafSprite = makeLeaf()
+	}
+	c := p.color
+	c.A = uint8(float64(c.A) * min(1, float64(p.life)/leafFade))
+	drawTumbling(screen, leafSprite, p.flake(), p.size*leafScale/spriteCell, 1, c)
+}
+
+// drawTumbling draws a flat sprite turned to the particle's angle and
+// squashed by flutter, from 1 for face-on to 0 for edge-on
+func drawTumbling(screen, sprite *ebiten.Image, f Snowflake, scale, flutter float64, c color.NRGBA) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-spriteCell/2, -spriteCell/2)
+	op.GeoM.Scale(1, max(math.Abs(flutter), 0.15))
+	op.GeoM.Rotate(f.angle)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(f.x, f.y)
+	op.ColorScale.ScaleWithColor(c)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(sprite, op)
+}
+
+// settleLeaf leaves a still copy of the leaf lying where it landed for a while
+func settleLeaf(g *Game, f *Snowflake) {
+	tint := autumnPalette[f.design%len(autumnPalette)]
+	g.debris.Emit(Particle{
+		x:      f.x,
+		y:      f.y - f.size*leafScale/4,
+		size:   f.size,
+		angle:  f.angle,
+		life:   leafRest,
+		span:   leafRest,
+		color:  color.NRGBA{tint.R, tint.G, tint.B, g.flakeTint(*f).A},
+		behave: restingLeafBehavior,
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Shape and timing of a lightning strike
+const (
+	lightningFrames = 14   // Frames a strike lights up the sky
+	lightningStep   = 24.0 // Pixels the bolt drops between kinks
+	lightningJitter = 18.0 // Furthest the bolt kinks sideways at each step
+	lightningReach  = 0.5  // Shortest a bolt reaches down, as a share of the screen height
+	lightningStorm  = 0.5  // How strong a storm must be before lightning strikes
+)
+
+// Brightness of the flash over the frames of a strike, a bright flash that
+// dies down and flickers once more
+var lightningFlicker = [lightningFrames]float64{1, 0.8, 0.4, 0.15, 0.05, 0, 0, 0.6, 0.45, 0.25, 0.12, 0.06, 0.03, 0.01}
+
+// Lightning is a strike during a storm
+type Lightning struct {
+	left int       // Frames left in the strike, 0 when none is showing
+	bolt []float32 // Points along the bolt as x, y pairs
+	fork []float32 // A smaller branch off the bolt, as x, y pairs
+}
+
+// updateLightning now and then strikes while a storm rages and moves any
+// strike on a frame
+func (g *Game) updateLightning(r *rand.Rand) {
+	l := &g.lightning
+	if l.left > 0 {
+		l.left--
+	}
+	if !g.config.Lightning || g.blizzard.level < lightningStorm {
+		return
+	}
+
+	// Strikes come at random, on average once per lightning_every seconds at the storm's peak
+	if l.left == 0 && r.Float64() < g.blizzard.level/(g.config.LightningEvery*float64(g.stepRate())) {
+		l.strike(g.screenWidth, g.screenHeight, r)
+	}
+}
+
+// strike starts a new strike with a jagged bolt from the top of the screen
+func (l *Lightning) strike(width, height int, r *rand.Rand) {
+	l.left = lightningFrames
+	x := float64(width) * (0.1 + 0.8*r.Float64())
+	bottom := float64(height) * (lightningReach + (1-lightningReach)*r.Float64())
+	l.bolt = jag(x, 0, bottom, r)
+
+	// A branch splits off somewhere along the upper half of the bolt
+	l.fork = nil
+	if n := len(l.bolt) / 2; n >= 4 {
+		i := r.Intn(n/2) * 2
+		fx, fy := float64(l.bolt[i]), float64(l.bolt[i+1])
+		l.fork = jag(fx, fy, fy+(bottom-fy)*0.4, r)
+	}
+}
+
+// jag returns the points of a bolt zigzagging down from x, top to bottom
+func jag(x, top, bottom float64, r *rand.Rand) []float32 {
+	points := []float32{float32(x), float32(top)}
+	for y := top; y < bottom; {
+		y += lightningStep * (0.5 + r.Float64())
+		x += (r.Float64()*2 - 1) * lightningJitter
+		points = append(points, float32(x), float32(y))
+	}
+	return points
+}
+
+// drawLightning lights up the sky and draws the bolt during a strike
+func (g *Game) drawLightning(screen *ebiten.Image) {
+	l := &g.lightning
+	if l.left == 0 {
+		return
+	}
+	bright := lightningFlicker[lightningFrames-l.left] * g.config.LightningBrightness
+	if bright <= 0 {
+		return
+	}
+
+	bounds := screen.Bounds()
+	flash := color.NRGBA{0xd8, 0xe0, 0xff, uint8(bright * 160)}
+	vector.DrawFilledRect(screen, 0, 0, float32(bounds.Dx()), float32(bounds.Dy()), flash, false)
+
+	halo := color.NRGBA{0xa0, 0xb0, 0xff, uint8(bright * 120)}
+	core := color.NRGBA{0xff, 0xff, 0xff, uint8(min(1, bright*2) * 255)}
+	for _, path := range [][]float32{l.bolt, l.fork} {
+		for i := 2; i+1 < len(path); i += 2 {
+			x0, y0, x1, y1 := path[i-2], path[i-1], path[i], path[i+1]
+			vector.StrokeLine(screen, x0, y0, x1, y1, 7, halo, true)
+			vector.StrokeLine(screen, x0, y0, x1, y1, 2, core, true)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Current version of the config file layout. Bump it and add a migration
+// whenever a key is renamed or restructured.
+const configVersion = 1
+
+// migrations[i] upgrades a raw config from version i to version i+1,
+// reporting whether it changed anything
+var migrations = []func(raw map[string]any) bool{
+	// Version 0 files predate the version key and need no other changes
+	func(raw map[string]any) bool { return false },
+}
+
+// migrateConfig upgrades the config file contents in data to the current
+// version. If anything changed, the original is kept beside path with a
+// .bak suffix and the upgraded file is written in its place.
+func migrateConfig(path string, data []byte) ([]byte, error) {
+	upgraded, version, err := upgradeConfig(path, data)
+	if err != nil || version == configVersion {
+		return upgraded, err
+	}
+
+	if err := os.WriteFile(path+".bak", data, 0o644); err != nil {
+		return data, fmt.Errorf("backing up %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, upgraded, 0o644); err != nil {
+		return data, fmt.Errorf("upgrading %s: %w", path, err)
+	}
+	log.Printf("Upgraded %s from version %d to %d (backup saved as %s.bak)", path, version, configVersion, path)
+	return upgraded, nil
+}
+
+// upgradeConfig upgrades the config file contents in data to the current
+// version in memory, leaving the file alone. It also returns the version
+// the file was at, which is configVersion if nothing needed changing.
+// Files the migrations leave as they are keep their comments and layout,
+// rather than being rewritten just to add the version key.
+func upgradeConfig(path string, data []byte) ([]byte, int, error) {
+	raw := map[string]any{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		// Leave syntax errors to be reported when the file is read for real
+		return data, configVersion, nil
+	}
+
+	version := 0
+	if v, ok := raw["version"].(int64); ok {
+		version = int(v)
+	}
+	if version > configVersion {
+		log.Printf("%s is from a newer version of winsnow (%d > %d); unknown keys will be ignored", path, version, configVersion)
+		return data, configVersion, nil
+	}
+	if version == configVersion {
+		return data, version, nil
+	}
+
+	changed := false
+	for v := version; v < configVersion; v++ {
+		changed = migrations[v](raw) || changed
+	}
+	if !changed {
+		return data, configVersion, nil
+	}
+	raw["version"] = configVersion
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return data, configVersion, fmt.Errorf("upgrading %s: %w", path, err)
+	}
+	return buf.Bytes(), version, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strconv"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/sys/windows"
+)
+
+// MonitorConfig holds settings that apply to one monitor only.
+// Unset keys keep the value from the rest of the config.
+type MonitorConfig struct {
+	Preset   *string  `toml:"preset" json:"preset,omitempty"`
+	Flakes   *int     `toml:"flakes" json:"flakes,omitempty"`
+	SpeedMin *float64 `toml:"speed_min" json:"speed_min,omitempty"`
+	SpeedMax *float64 `toml:"speed_max" json:"speed_max,omitempty"`
+	SizeMin  *float64 `toml:"size_min" json:"size_min,omitempty"`
+	SizeMax  *float64 `toml:"size_max" json:"size_max,omitempty"`
+	Wind     *float64 `toml:"wind" json:"wind,omitempty"`
+	Color    *string  `toml:"color" json:"color,omitempty"`
+	Opacity  *float64 `toml:"opacity" json:"opacity,omitempty"`
+}
+
+// ApplyMonitorSection applies the [monitors] sections matching the monitor's
+// index and device name, in that order, so a section keyed by name wins
+func (c *Config) ApplyMonitorSection(index int, name string) error {
+	for _, key := range []string{strconv.Itoa(index), name} {
+		m, ok := c.Monitors[key]
+		if !ok || key == "" {
+			continue
+		}
+
+		// The preset goes first so the other keys can tweak it
+		if m.Preset != nil {
+			if err := c.ApplyPreset(*m.Preset); err != nil {
+				return err
+			}
+		}
+		if m.Flakes != nil {
+			c.SetFlakes(*m.Flakes)
+		}
+		setIf(&c.SpeedMin, m.SpeedMin)
+		setIf(&c.SpeedMax, m.SpeedMax)
+		setIf(&c.SizeMin, m.SizeMin)
+		setIf(&c.SizeMax, m.SizeMax)
+		setIf(&c.Wind, m.Wind)
+		setIf(&c.Color, m.Color)
+		setIf(&c.Opacity, m.Opacity)
+	}
+	return nil
+}
+
+// setIf copies *v into dst if v is set
+func setIf[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// monitorName returns the device name of the monitor at index, or "" if there is none
+func monitorName(index int) string {
+	monitors := ebiten.AppendMonitors(nil)
+	if index < 0 || index >= len(monitors) {
+		return ""
+	}
+	return monitors[index].Name()
+}
+
+// The monitors found by the last enumeration
+var displayInfos []monitorInfo
+
+// collectDisplay is the EnumDisplayMonitors callback
+var collectDisplay = windows.NewCallback(func(monitor, hdc, clip, lParam uintptr) uintptr {
+	var mi monitorInfo
+	mi.cbSize = uint32(unsafe.Sizeof(mi))
+	if ok, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&mi))); ok != 0 {
+		displayInfos = append(displayInfos, mi)
+	}
+	return 1
+})
+
+// Displays returns the bounds of every connected monitor in physical
+// pixels, relative to the top-left corner of the primary monitor. With
+// workArea set the taskbar and docked toolbars are left out.
+func Displays(workArea bool) []rect {
+	displayInfos = nil
+	procEnumDisplayMonitors.Call(0, 0, collectDisplay, 0)
+
+	displays := make([]rect, len(displayInfos))
+	for i, mi := range displayInfos {
+		displays[i] = monitorBounds(mi, workArea)
+	}
+	return displays
+}
+
+// VirtualDesktop returns the rectangle enclosing all the monitors
+func VirtualDesktop(workArea bool) rect {
+	displays := Displays(workArea)
+	if len(displays) == 0 {
+		return rect{}
+	}
+	vd := displays[0]
+	for _, d := range displays[1:] {
+		vd.left = min(vd.left, d.left)
+		vd.top = min(vd.top, d.top)
+		vd.right = max(vd.right, d.right)
+		vd.bottom = max(vd.bottom, d.bottom)
+	}
+	return vd
+}
+
+// monitorBounds returns the whole monitor, or just its work area
+func monitorBounds(mi monitorInfo, workArea bool) rect {
+	if workArea {
+		return mi.rcWork
+	}
+	return mi.rcMonitor
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How the moon looks and where it hangs
+const (
+	moonRadius     = 36           // Radius of the moon on screen in pixels
+	moonCell       = 128          // Width and height the moon is drawn at before it is scaled down
+	moonX          = 0.82         // Share of the way across the screen the moon hangs
+	moonY          = 0.14         // Share of the way down the screen the moon hangs
+	moonEarthshine = 0.08         // Brightness of the dark part of the moon, lit faintly by the earth
+	moonSynodic    = 29.530588853 // Days from one new moon to the next
+)
+
+// A new moon to count the phase from
+var newMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// How the clouds drift
+const (
+	cloudTextures = 3    // Different clouds to pick from
+	cloudSpacing  = 400  // Pixels of screen width per cloud
+	cloudScaleMin = 1.2  // Smallest a cloud is drawn, times its texture size
+	cloudScaleMax = 2.5  // Largest a cloud is drawn, times its texture size
+	cloudDriftMax = 0.15 // Fastest a cloud drifts on its own in pixels per frame
+	cloudWind     = 0.1  // Share of the wind that carries the clouds along
+	cloudTop      = 0.45 // Share of the way down the screen the clouds reach
+	cloudPhase    = 53.9 // Offset into the noise for the cloud textures, so they differ from the fog
+)
+
+// Moon is the moon and the clouds drifting in front of it, behind the snow
+type Moon struct {
+	image  *ebiten.Image
+	phase  float64 // Phase the image was drawn for
+	clouds Fog     // Clouds drift like the fog, only higher up and slower
+}
+
+// moonPhase returns how far through its cycle the moon is at t, from 0 at
+// new moon through 0.5 at full moon and back
+func moonPhase(t time.Time) float64 {
+	days := t.Sub(newMoon).Hours() / 24
+	return math.Mod(days/moonSynodic, 1)
+}
+
+// makeMoon draws the moon lit for its phase, with darker seas across its
+// face and the unlit part glowing faintly
+func makeMoon(n *Noise, phase float64) *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, moonCell, moonCell))
+	half := float64(moonCell) / 2
+	k := math.Cos(2 * math.Pi * phase)
+	for py := range moonCell {
+		for px := range moonCell {
+			x := (float64(px) + 0.5 - half) / half
+			y := (float64(py) + 0.5 - half) / half
+			if x*x+y*y > 1 {
+				continue
+			}
+
+			// The line between day and night sweeps across the face and back
+			w := math.Sqrt(1 - y*y)
+			lit := x > k*w
+			if phase > 0.5 {
+				lit = x < -k*w
+			}
+			bright := moonEarthshine
+			if lit {
+				sea := n.At(x*2.5, y*2.5, 7.1)
+				bright = 0.85 + 0.15*sea
+			}
+			// A soft edge
+			edge := min(1, (1-math.Hypot(x, y))*half)
+			v := uint8(min(bright, 1) * 255)
+			img.SetNRGBA(px, py, color.NRGBA{v, v, uint8(min(bright*1.05, 1) * 255), uint8(edge * 255)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// updateMoon draws the moon afresh as its phase changes and drifts the
+// clouds along with the wind
+func (g *Game) updateMoon(r *rand.Rand) {
+	m := &g.moon
+	if g.config.Moon {
+		if phase := moonPhase(time.Now()); m.image == nil || math.Abs(phase-m.phase) > 0.01 {
+			m.image = makeMoon(g.noise, phase)
+			m.phase = phase
+		}
+	} else {
+		m.image = nil
+	}
+
+	clouds := &m.clouds
+	if g.config.Clouds <= 0 {
+		clouds.patches = nil
+		return
+	}
+	if clouds.textures == nil {
+		for i := range cloudTextures {
+			clouds.textures = append(clouds.textures, makeFog(g.noise, cloudPhase+float64(i)*fogPhase))
+		}
+	}
+	if clouds.patches == nil || clouds.width != g.screenWidth {
+		clouds.width = g.screenWidth
+		clouds.patches = make([]fogPatch, g.screenWidth/cloudSpacing+2)
+		for i := range clouds.patches {
+			scale := cloudScaleMin + r.Float64()*(cloudScaleMax-cloudScaleMin)
+			clouds.patches[i] = fogPatch{
+				x:       r.Float64()*float64(g.screenWidth+fogWidth) - fogWidth,
+				y:       r.Float64()*float64(g.screenHeight)*cloudTop - fogHeight*scale/2,
+				scale:   scale,
+				drift:   (r.Float64()*2 - 1) * cloudDriftMax,
+				texture: r.Intn(cloudTextures),
+			}
+		}
+	}
+	for i := range clouds.patches {
+		p := &clouds.patches[i]
+		p.x += p.drift + g.wind*cloudWind
+		w := fogWidth * p.scale
+		if p.x > float64(g.screenWidth) {
+			p.x = -w
+		} else if p.x < -w {
+			p.x = float64(g.screenWidth)
+		}
+	}
+}
+
+// drawMoon draws the moon and the clouds drifting across the sky in front of it
+func (g *Game) drawMoon(screen *ebiten.Image) {
+	if m := g.moon.image; m != nil {
+		scale := moonRadius * 2.0 / moonCell
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(float64(g.screenWidth)*moonX-moonRadius, float64(g.screenHeight)*moonY-moonRadius)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(m, op)
+	}
+
+	for _, p := range g.moon.clouds.patches {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(p.scale, p.scale*0.6) // Flatter than the fog
+		op.GeoM.Translate(p.x, p.y)
+		op.ColorScale.ScaleAlpha(float32(g.config.Clouds))
+		g.tintHaze(&op.ColorScale)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(g.moon.clouds.textures[p.texture], op)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// MessageWindow is a hidden top-level window with its own message loop.
+// It receives tray icon clicks and the system broadcasts that only reach
+// top-level windows, and hands them to the registered handlers.
+type MessageWindow struct {
+	hwnd     uintptr
+	handlers map[uint32][]func(wParam, lParam uintptr) uintptr
+}
+
+// There is only ever one message window, which the window procedure dispatches to
+var messageWindow *MessageWindow
+
+// wndClassEx mirrors the Windows WNDCLASSEXW structure
+type wndClassEx struct {
+	cbSize        uint32
+	style         uint32
+	lpfnWndProc   uintptr
+	cbClsExtra    int32
+	cbWndExtra    int32
+	hInstance     uintptr
+	hIcon         uintptr
+	hCursor       uintptr
+	hbrBackground uintptr
+	lpszMenuName  *uint16
+	lpszClassName *uint16
+	hIconSm       uintptr
+}
+
+// StartMessageWindow creates the message window on a dedicated OS thread.
+// setup runs on that thread once the window exists, which is where
+// handlers must be registered; it then runs the message loop until exit.
+func StartMessageWindow(setup func(w *MessageWindow)) {
+	go func() {
+		// Window messages are delivered to the thread that created the window
+		runtime.LockOSThread()
+
+		w := &MessageWindow{handlers: map[uint32][]func(wParam, lParam uintptr) uintptr{}}
+		className, _ := windows.UTF16PtrFromString("winsnowMessageWindow")
+		instance, _, _ := procGetModuleHandle.Call(0)
+
+		wc := wndClassEx{
+			lpfnWndProc:   windows.NewCallback(messageWindowProc),
+			hInstance:     instance,
+			lpszClassName: className,
+		}
+		wc.cbSize = uint32(unsafe.Sizeof(wc))
+		if atom, _, err := procRegisterClassEx.Call(uintptr(unsafe.Pointer(&wc))); atom == 0 {
+			log.Println("Could not register message window class:", err)
+			return
+		}
+
+		hwnd, _, err := procCreateWindowEx.Call(
+			0,
+			uintptr(unsafe.Pointer(className)),
+			uintptr(unsafe.Pointer(className)),
+			0,          // Never shown
+			0, 0, 0, 0, // No size or position
+			0, 0, instance, 0,
+		)
+		if hwnd == 0 {
+			log.Println("Could not create message window:", err)
+			return
+		}
+		w.hwnd = hwnd
+		messageWindow = w
+
+		setup(w)
+
+		var m msg
+		for {
+			ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if int32(ret) <= 0 {
+				return
+			}
+			procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+			procDispatchMessage.Call(uintptr(unsafe.Pointer(&m)))
+		}
+	}()
+}
+
+// Handle registers fn to answer message. Several handlers can share a
+// message; they are called in order and the last one's result is returned.
+// Call it from the setup function only.
+func (w *MessageWindow) Handle(message uint32, fn func(wParam, lParam uintptr) uintptr) {
+	w.handlers[message] = append(w.handlers[message], fn)
+}
+
+// messageWindowProc dispatches messages to the registered handlers
+func messageWindowProc(hwnd uintptr, message uint32, wParam, lParam uintptr) uintptr {
+	if w := messageWindow; w != nil && w.hwnd == hwnd {
+		if fns, ok := w.handlers[message]; ok {
+			var ret uintptr
+			for _, fn := range fns {
+				ret = fn(wParam, lParam)
+			}
+			return ret
+		}
+	}
+	ret, _, _ := procDefWindowProc.Call(hwnd, uintptr(message), wParam, lParam)
+	return ret
+}
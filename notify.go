@@ -0,0 +1,32 @@
+package main
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for balloon notifications, which Windows 10 and later show as toasts
+const (
+	NIM_MODIFY = 0x00000001
+	NIF_INFO   = 0x00000010
+	NIIF_INFO  = 0x00000001
+)
+
+// Notify shows a notification from the tray icon
+func (t *Tray) Notify(title, text string) {
+	// Work on a copy so the icon data used to re-add the icon is left alone
+	nid := t.nid
+	nid.uFlags = NIF_INFO
+	nid.dwInfoFlags = NIIF_INFO
+	copy(nid.szInfoTitle[:len(nid.szInfoTitle)-1], windows.StringToUTF16(title))
+	copy(nid.szInfo[:len(nid.szInfo)-1], windows.StringToUTF16(text))
+	procShellNotifyIcon.Call(NIM_MODIFY, uintptr(unsafe.Pointer(&nid)))
+}
+
+// notify shows a notification if notifications are turned on
+func (g *Game) notify(title, text string) {
+	if g.config.Notifications && g.tray != nil {
+		g.tray.Notify(title, text)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+)
+
+// Names of the color palettes, in the order they are offered
+var paletteNames = []string{"classic", "golden", "pastel", "neon", "candy-cane"}
+
+// Colors each palette's flakes are picked from as #rrggbb. Classic has none
+// of its own and keeps the configured flake color.
+var palettes = map[string][]string{
+	"classic":    nil,
+	"golden":     {"#fff4d6", "#ffe08a", "#f5c542", "#e0a82e"},
+	"pastel":     {"#ffd1dc", "#c9f0ff", "#d7f5d0", "#fff1b8", "#e2d4ff"},
+	"neon":       {"#ff2bd6", "#2bfff0", "#a6ff2b", "#fff02b", "#ff8a2b"},
+	"candy-cane": {"#ffffff", "#ffffff", "#e8202a"},
+}
+
+// validatePalette checks the palette setting
+func validatePalette(name string) error {
+	if _, ok := palettes[name]; !ok {
+		return fmt.Errorf("palette must be one of %s, got %q", strings.Join(paletteNames, ", "), name)
+	}
+	return nil
+}
+
+// SetPalette switches to the named palette at runtime
+func (g *Game) SetPalette(name string) error {
+	if err := validatePalette(name); err != nil {
+		return err
+	}
+	cfg := g.config
+	cfg.Palette = name
+	g.ApplyConfig(cfg)
+	return nil
+}
+
+// updatePalette recomputes the palette colors, tinted and faded like the
+// flake color. Effects with a color of their own and high contrast mode
+// keep the single flake color.
+func (g *Game) updatePalette(cfg Config) {
+	g.palette = g.palette[:0]
+	if e, ok := effects[cfg.Effect]; g.highContrast || ok && e.color != "" {
+		return
+	}
+	for _, s := range palettes[cfg.Palette] {
+		if cfg.DayNight {
+			s = tintColor(s, cfg.dayTint(time.Now()))
+		}
+		c, _ := ParseColor(s)
+		g.palette = append(g.palette, color.NRGBA{c.R, c.G, c.B, g.flakeColor.A})
+	}
+}
+
+// flakeTint returns the color a flake is drawn in
+func (g *Game) flakeTint(f Snowflake) color.NRGBA {
+	if len(g.palette) == 0 {
+		return g.flakeColor
+	}
+	return g.palette[int(f.tint*float64(len(g.palette)))%len(g.palette)]
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Most particles alive at once; more are dropped so a busy scene can't slow the snow
+const particleLimit = 5000
+
+// Behavior is how a kind of particle moves and is drawn
+type Behavior struct {
+	gravity float64 // Pull in pixels per frame per frame, negative for particles that rise
+	drag    float64 // Share of its speed a particle keeps from one frame to the next
+	wind    float64 // Share of the wind that carries it along
+	grow    float64 // Pixels its size grows each frame
+	front   bool    // Drawn over the snow instead of behind it
+	draw    func(g *Game, screen *ebiten.Image, p Particle)
+
+	// move moves a particle further each frame after gravity, drag and the
+	// wind have, nil for nothing more. Setting its life to 0 puts it out.
+	move func(g *Game, p *Particle)
+}
+
+// Particle is a short-lived speck such as a spark, a wisp of mist, a piece
+// of confetti or a clump of debris, moving on its own until its life runs
+// out. The falling flakes are not particles: they clump together and are
+// respawned rather than dying, so they keep their own loop.
+type Particle struct {
+	x, y   float64
+	vx, vy float64
+	size   float64
+	speed  float64 // Terminal speed, for debris falling like the snow
+	angle  float64 // Rotation in radians, for particles that tumble
+	phase  float64 // Point in its sway, for particles that rock from side to side
+	life   int     // Frames left
+	span   int     // Frames it lasts in all
+	color  color.NRGBA
+	behave *Behavior
+}
+
+// age returns how far through its life the particle is, from 0 to 1
+func (p Particle) age() float64 {
+	return 1 - float64(p.life)/float64(p.span)
+}
+
+// Pool holds the particles alive, reusing the room of those that have gone
+// out for new ones
+type Pool struct {
+	particles []Particle
+}
+
+// Emit adds a particle to the pool, unless it is full
+func (pool *Pool) Emit(p Particle) {
+	if len(pool.particles) < particleLimit {
+		pool.particles = append(pool.particles, p)
+	}
+}
+
+// Len returns how many particles are alive
+func (pool *Pool) Len() int {
+	return len(pool.particles)
+}
+
+// Update moves each particle a frame along as its behavior says, and
+// removes those whose life has run out
+func (pool *Pool) Update(g *Game) {
+	alive := pool.particles[:0]
+	for _, p := range pool.particles {
+		if p.life--; p.life <= 0 {
+			continue
+		}
+		b := p.behave
+		p.vx *= b.drag
+		p.vy = p.vy*b.drag + b.gravity
+		p.x += p.vx + g.wind*b.wind
+		p.y += p.vy
+		p.size += b.grow
+		if b.move != nil {
+			if b.move(g, &p); p.life <= 0 {
+				continue
+			}
+		}
+		alive = append(alive, p)
+	}
+	pool.particles = alive
+}
+
+// Draw draws the particles that belong behind or over the snow
+func (pool *Pool) Draw(g *Game, screen *ebiten.Image, front bool) {
+	for _, p := range pool.particles {
+		if p.behave.front == front {
+			p.behave.draw(g, screen, p)
+		}
+	}
+}
+
+// Emitter sends particles into a pool at a steady rate while it runs
+type Emitter struct {
+	rate  float64 // Particles each frame, which need not be whole
+	owed  float64 // Part of a particle carried over to the next frame
+	spawn func(r *rand.Rand) Particle
+}
+
+// Run emits this frame's share of particles
+func (e *Emitter) Run(pool *Pool, r *rand.Rand) {
+	e.owed += e.rate
+	for ; e.owed >= 1; e.owed-- {
+		pool.Emit(e.spawn(r))
+	}
+}
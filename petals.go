@@ -0,0 +1,62 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How cherry blossom petals look and behave
+const (
+	petalSpeed  = 0.25 // Times as fast as snow petals fall
+	petalSway   = 4.0  // Times as far as snow petals swing around their spiral
+	petalSpiral = 0.6  // How far petals bob up and down as they spiral, relative to the sway
+	petalCalm   = 0.5  // Share of the wind petals ignore, so they drift gently
+	petalScale  = 3.5  // Petals are drawn this many times the flake size
+)
+
+// Pinks the petals are picked from
+var sakuraPalette = []color.NRGBA{
+	{0xff, 0xb7, 0xc5, 0xff}, // Blossom pink
+	{0xff, 0xc8, 0xd6, 0xff}, // Pale pink
+	{0xf7, 0xa1, 0xb8, 0xff}, // Deeper pink
+	{0xff, 0xe4, 0xec, 0xff}, // Almost white
+}
+
+// The petal shape, white on transparent, made on first use
+var petalSprite *ebiten.Image
+
+// makePetal draws a rounded petal with the little notch at its tip
+func makePetal() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, spriteCell, spriteCell))
+	half := float64(spriteCell) / 2
+	for py := 0; py < spriteCell; py++ {
+		for px := 0; px < spriteCell; px++ {
+			// Along the petal from -1 at its base to 1 at its tip, and across it
+			u := (float64(px) + 0.5 - half) / (half - 2)
+			v := (float64(py) + 0.5 - half) / (half - 2)
+			width := 0.5 * math.Sqrt(max(0, 1-u*u)) * (0.6 + 0.4*(u+1)/2)
+			notch := u > 0.75 && math.Abs(v) < (u-0.75)*0.6
+
+			a := uint8(0)
+			if math.Abs(u) <= 1 && math.Abs(v) < width && !notch {
+				// Paler toward the base, as real petals are
+				a = uint8(200 + 55*(u+1)/2)
+			}
+			img.SetNRGBA(px, py, color.NRGBA{255, 255, 255, a})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// drawPetal draws a petal in its own pink, turning as it spirals down
+func drawPetal(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	if petalSprite == nil {
+		petalSprite = makePetal()
+	}
+	tint := sakuraPalette[f.design%len(sakuraPalette)]
+	flutter := math.Sin(f.phase)
+	drawTumbling(screen, petalSprite, f, f.size*petalScale/spriteCell, flutter, color.NRGBA{tint.R, tint.G, tint.B, c.A})
+}
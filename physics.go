@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Forces on a falling flake
+const (
+	gravity  = 0.5 // Downward acceleration in pixels per frame per frame
+	sizeBias = 0.7 // How much of a flake's terminal speed comes from its size rather than chance
+)
+
+// Farthest a flake can move in one step and still be drawn part way along;
+// further than that it has wrapped around or been respawned
+const tweenLimit = 100.0
+
+// terminalSpeed picks how fast a new flake falls once air drag balances
+// its weight. Drag grows with a flake's width but its weight with its
+// volume, so big wet flakes fall fastest and fine powder floats.
+func (c *Config) terminalSpeed(size float64, r *rand.Rand) float64 {
+	heavy := 0.0
+	if c.SizeMax > c.SizeMin {
+		t := (size - c.SizeMin) / (c.SizeMax - c.SizeMin)
+		heavy = t * t
+	}
+	mix := heavy*sizeBias + r.Float64()*(1-sizeBias)
+	return c.SpeedMin + mix*(c.SpeedMax-c.SpeedMin)
+}
+
+// fall accelerates the flake under gravity and drags it toward the speed
+// of the air around it. The drag per unit of weight is what makes the
+// flake's terminal speed, so slow, light flakes are swept along by gusts
+// almost at once while heavy ones take a while to respond.
+func (g *Game) fall(f *Snowflake, airX, airY float64) {
+	e := g.effect()
+	terminal := f.speed * e.speed * g.blizzardSpeedScale()
+	drag := min(gravity/terminal, 1)
+	airX *= 1 - e.calm
+
+	f.vx += (airX - f.vx) * drag
+	f.vy += gravity - (f.vy-airY)*drag
+}
+
+// tween moves a flake to where it is drawn, part way from where it was
+// before the last physics step to where it is now by the share of the next
+// step already due. With more ticks than steps, the ticks that run no step
+// still show the snow moving smoothly.
+func (g *Game) tween(f *Snowflake) {
+	dx, dy := f.x-f.px, f.y-f.py
+	if math.Abs(dx) > tweenLimit || math.Abs(dy) > tweenLimit {
+		return
+	}
+	f.x = f.px + dx*g.owed
+	f.y = f.py + dy*g.owed
+}
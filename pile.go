@@ -0,0 +1,238 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Shape of the snow piles
+const (
+	pileColumnWidth = 2    // Width of each column of a pile in pixels
+	pileDeposit     = 0.15 // Depth added per square pixel of a landed flake, spread over a column
+	pileRepose      = 1.5  // Steepest height difference between neighbouring columns before snow slides
+	pileSlide       = 0.25 // Share of the excess that slides to the lower neighbour each tick
+	driftThreshold  = 0.2  // Weakest wind that blows settled snow along
+	driftRate       = 0.01 // Share of a column blown along each tick for each unit of wind above the threshold
+	driftMax        = 0.2  // Most of a column blown along in one tick
+)
+
+// Pile is snow lying on a horizontal edge, such as the top of the taskbar,
+// kept as the depth of each column along it
+type Pile struct {
+	kind  surfaceKind
+	x, y  float64   // Left end of the edge and its height on screen
+	depth []float64 // Snow depth of each column in pixels
+	dent  []float64 // Depth of each column pressed down by footprints
+	slide avalanche // A drift collapsing off the edge
+}
+
+// NewPile creates an empty pile along the edge from x to x+width at height y
+func NewPile(kind surfaceKind, x, y, width float64) *Pile {
+	n := max(int(width/pileColumnWidth), 1)
+	return &Pile{kind: kind, x: x, y: y, depth: make([]float64, n), dent: make([]float64, n)}
+}
+
+// width returns the length of the edge the pile lies on
+func (p *Pile) width() float64 {
+	return float64(len(p.depth) * pileColumnWidth)
+}
+
+// On reports whether the pile lies on the edge from x to x+width at height y
+func (p *Pile) On(x, y, width float64) bool {
+	return p.x == x && p.y == y && len(p.depth) == max(int(width/pileColumnWidth), 1)
+}
+
+// column returns the index of the column under x, or -1 if x is off the edge
+func (p *Pile) column(x float64) int {
+	if x < p.x || x >= p.x+p.width() {
+		return -1
+	}
+	return int((x - p.x) / pileColumnWidth)
+}
+
+// Catch lands the flake on the pile if it crossed the snow surface since
+// it was at prevY, and reports whether it did
+func (p *Pile) Catch(f *Snowflake, prevY, maxDepth float64) bool {
+	if !p.Hit(f, prevY) {
+		return false
+	}
+	col := p.column(f.x)
+	add := f.size * f.size * pileDeposit / pileColumnWidth
+	if p.dent[col] > 0 {
+		// Fresh snow fills in a footprint before it builds up
+		p.dent[col] = max(p.dent[col]-add, 0)
+	} else {
+		p.depth[col] = min(p.depth[col]+add, maxDepth)
+	}
+	return true
+}
+
+// Hit reports whether the flake has fallen onto the pile since it was at prevY
+func (p *Pile) Hit(f *Snowflake, prevY float64) bool {
+	col := p.column(f.x)
+	if col < 0 {
+		return false
+	}
+	surface := p.top(col)
+	return prevY <= surface && f.y >= surface
+}
+
+// top returns the height of the snow surface over a column
+func (p *Pile) top(col int) float64 {
+	return p.y - max(p.depth[col]-p.dent[col], 0)
+}
+
+// Settle blows snow downwind, lets it slide off slopes steeper than it can
+// hold, forming drifts instead of spikes, and melts melt pixels from every column
+func (p *Pile) Settle(melt, wind, maxDepth float64) {
+	p.blow(wind, maxDepth)
+	for i := 0; i+1 < len(p.depth); i++ {
+		diff := p.depth[i] - p.depth[i+1]
+		if diff > pileRepose {
+			move := (diff - pileRepose) * pileSlide
+			p.depth[i] -= move
+			p.depth[i+1] += move
+		} else if diff < -pileRepose {
+			move := (-diff - pileRepose) * pileSlide
+			p.depth[i] += move
+			p.depth[i+1] -= move
+		}
+	}
+	for i := range p.depth {
+		p.depth[i] = max(p.depth[i]-melt, 0)
+		p.dent[i] = min(max(p.dent[i]-footprintRefill, 0), p.depth[i])
+	}
+}
+
+// blow carries snow one column along the pile in the direction of the wind,
+// which is positive to the right. Snow stops where it meets a rise steeper
+// than it can climb or the end of the pile, so it gathers in the lee of
+// bumps and against the downwind end.
+func (p *Pile) blow(wind, maxDepth float64) {
+	if math.Abs(wind) < driftThreshold {
+		return
+	}
+	rate := min((math.Abs(wind)-driftThreshold)*driftRate, driftMax)
+
+	// Work upwind from the downwind end, so each tick snow moves one column
+	n := len(p.depth)
+	for k := n - 2; k >= 0; k-- {
+		from, to := k, k+1
+		if wind < 0 {
+			from, to = n-1-k, n-2-k
+		}
+		if p.depth[to]-p.depth[from] > pileRepose || p.depth[to] >= maxDepth {
+			continue
+		}
+		move := min(p.depth[from]*rate, maxDepth-p.depth[to])
+		p.depth[from] -= move
+		p.depth[to] += move
+	}
+}
+
+// Draw draws the snow in the pile
+func (p *Pile) Draw(screen *ebiten.Image, c color.Color) {
+	for i := range p.depth {
+		d := p.y - p.top(i)
+		if d < 0.5 {
+			continue
+		}
+		x := p.x + float64(i*pileColumnWidth)
+		vector.DrawFilledRect(screen, float32(x), float32(p.y-d), pileColumnWidth, float32(d), c, false)
+	}
+}
+
+// Clumps of snow knocked off a pile
+const (
+	clumpColumns  = 3   // Columns of snow gathered into each clump
+	clumpMinDepth = 1   // Shallower snow just disappears
+	clumpMaxSize  = 6.0 // Largest clump diameter in pixels
+)
+
+// knockOff breaks the pile up into clumps that fall as debris, for when
+// the window it lies on is moved or shaken
+func (g *Game) knockOff(p *Pile) {
+	for i := 0; i < len(p.depth); i += clumpColumns {
+		depth := 0.0
+		for _, d := range p.depth[i:min(i+clumpColumns, len(p.depth))] {
+			depth = max(depth, d)
+		}
+		if depth < clumpMinDepth {
+			continue
+		}
+		g.emitDebris(Snowflake{
+			x:     p.x + float64(i*pileColumnWidth) + g.rng.Float64()*clumpColumns*pileColumnWidth,
+			y:     p.y - depth,
+			size:  min(depth, clumpMaxSize),
+			speed: g.config.SpeedMax, // Packed snow is heavy and falls fast
+		})
+	}
+}
+
+// land settles the flake on the first pile it has fallen onto and reports
+// whether it did. Particles of effects that don't pile up land without
+// adding to it.
+func (g *Game) land(f *Snowflake, prevY float64) bool {
+	// Piles lie on the tops of things, which only catch snow falling down,
+	// and are given up when the quality governor needs to lighten the load
+	if !g.config.fallsDown() || g.quality >= qualityNoPiles {
+		return false
+	}
+	piles := g.effect().piles
+	catch := func(p *Pile) bool {
+		if !piles {
+			return p.Hit(f, prevY)
+		}
+		return p.Catch(f, prevY, g.maxDepth(p.kind))
+	}
+
+	for _, p := range g.grid.PilesAt(f.x) {
+		if catch(p) {
+			return true
+		}
+	}
+	if p := g.cursor.pile; p != nil {
+		return catch(p)
+	}
+	return false
+}
+
+// maxDepth returns how deep snow can pile up on a kind of surface
+func (g *Game) maxDepth(kind surfaceKind) float64 {
+	switch kind {
+	case surfaceGround:
+		return g.config.GroundMaxDepth
+	case surfaceCursor:
+		return cursorMaxDepth
+	}
+	return g.config.PileMaxDepth
+}
+
+// updateGround adds or removes the pile along the bottom of the screen
+// to match the settings and the screen size
+func (g *Game) updateGround() {
+	if !g.config.GroundSnow {
+		delete(g.piles, groundPile)
+		return
+	}
+	x, y, width := 0.0, float64(g.screenHeight), float64(g.screenWidth)
+	if p, ok := g.piles[groundPile]; !ok || !p.On(x, y, width) {
+		g.piles[groundPile] = NewPile(surfaceGround, x, y, width)
+		g.restorePile(groundPile, g.piles[groundPile])
+	}
+}
+
+// settlePiles lets the piles blow, slide and melt a little every tick
+func (g *Game) settlePiles() {
+	melt := g.config.MeltRate / float64(g.stepRate())
+	wind := g.baseWind() * g.config.SnowDrift
+	for _, p := range g.piles {
+		p.Settle(melt, wind, g.maxDepth(p.kind))
+	}
+	if p := g.cursor.pile; p != nil {
+		p.Settle(melt, wind, g.maxDepth(p.kind))
+	}
+}
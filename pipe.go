@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for the command pipes
+const (
+	PIPE_REJECT_REMOTE_CLIENTS = 0x00000008
+	pipeBuffer                 = 512 // Bytes buffered each way; commands and replies are one short line
+)
+
+// pipeName returns the name of a command pipe. It is named for the user
+// and their logon session, so each user's desktop session has its own.
+func pipeName(kind string) (string, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return "", err
+	}
+	var session uint32
+	if err := windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`\\.\pipe\winsnow-%s-%s-%d`, kind, sid, session), nil
+}
+
+// currentUserSID returns the SID of the user running winsnow as a string
+func currentUserSID() (string, error) {
+	u, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return u.User.Sid.String(), nil
+}
+
+// PipeListener accepts connections on a named pipe that only the current
+// user can open, and only from this machine
+type PipeListener struct {
+	name *uint16
+	sa   windows.SecurityAttributes
+	next windows.Handle // The instance waiting for the next client
+}
+
+// ListenPipe starts listening on the command pipe of the given kind. It
+// fails if the pipe already exists, which usually means another instance
+// is already listening.
+func ListenPipe(kind string) (*PipeListener, error) {
+	name, err := pipeName(kind)
+	if err != nil {
+		return nil, err
+	}
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, err
+	}
+	// Full access for the user and nobody else
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + sid + ")")
+	if err != nil {
+		return nil, err
+	}
+
+	l := &PipeListener{}
+	if l.name, err = windows.UTF16PtrFromString(name); err != nil {
+		return nil, err
+	}
+	l.sa.Length = uint32(unsafe.Sizeof(l.sa))
+	l.sa.SecurityDescriptor = sd
+	if l.next, err = l.create(windows.FILE_FLAG_FIRST_PIPE_INSTANCE); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// create makes another instance of the pipe for a client to connect to
+func (l *PipeListener) create(flags uint32) (windows.Handle, error) {
+	return windows.CreateNamedPipe(l.name, windows.PIPE_ACCESS_DUPLEX|flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, pipeBuffer, pipeBuffer, 0, &l.sa)
+}
+
+// Accept waits for a client to connect and returns the connection to it
+func (l *PipeListener) Accept() (*PipeConn, error) {
+	h := l.next
+	for {
+		err := windows.ConnectNamedPipe(h, nil)
+		if err == nil || err == windows.ERROR_PIPE_CONNECTED {
+			break
+		}
+		if err != windows.ERROR_NO_DATA {
+			return nil, err
+		}
+		// The client left before we saw it; wait for the next one
+		windows.DisconnectNamedPipe(h)
+	}
+
+	next, err := l.create(0)
+	if err != nil {
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	l.next = next
+	return &PipeConn{os.NewFile(uintptr(h), "pipe")}, nil
+}
+
+// Close stops listening
+func (l *PipeListener) Close() error {
+	return windows.CloseHandle(l.next)
+}
+
+// PipeConn is the server end of a connection on a command pipe
+type PipeConn struct {
+	*os.File
+}
+
+// Close waits for the client to read the reply, then hangs up
+func (c *PipeConn) Close() error {
+	windows.FlushFileBuffers(windows.Handle(c.Fd()))
+	return c.File.Close()
+}
+
+// DialPipe connects to the command pipe of the given kind, waiting up to
+// timeout while every instance of it is busy
+func DialPipe(kind string, timeout time.Duration) (*os.File, error) {
+	name, err := pipeName(kind)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(name, os.O_RDWR, 0)
+		if err == nil || !errors.Is(err, windows.ERROR_PIPE_BUSY) || time.Now().After(deadline) {
+			return f, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How the plow drives and pushes the snow
+const (
+	plowWidth    = 120  // Width of the plow in pixels
+	plowHeight   = 52   // Height of the plow in pixels
+	plowSpeed    = 2.5  // Pixels per frame the plow drives
+	plowMound    = 1.5  // Pixels the mound ahead of the blade rises for each square root pixel of snow pushed
+	plowMoundMax = 40.0 // Tallest the mound ahead of the blade gets, in pixels
+	plowSpray    = 0.3  // Chance each frame a little snow sprays off the mound
+)
+
+// Color of the plow against the snow, outlined like the sleigh
+var plowColor = color.NRGBA{0x2a, 0x2e, 0x38, 0xff}
+
+// Snow thrown off the top of the mound falls back, over the snow
+var sprayBehavior = &Behavior{gravity: 0.15, drag: 0.98, front: true, draw: drawSpray}
+
+// The plow facing right, made on first use
+var plowSprite *ebiten.Image
+
+// Plow is a snow plow driving along the bottom of the screen, clearing the
+// snow on the ground and pushing it along ahead of its blade
+type Plow struct {
+	driving bool
+	x       float64   // Left edge of the sprite
+	dir     float64   // 1 driving right or -1 driving left
+	pushed  float64   // Snow piled up ahead of the blade, in pixels of depth
+	next    time.Time // When the next plow is due, zero if none is
+}
+
+// SendPlow sends the plow along the bottom of the screen now, unless it is
+// already on its way or there is no snow on the ground to clear
+func (g *Game) SendPlow() {
+	pl := &g.plow
+	if _, ok := g.piles[groundPile]; pl.driving || !ok {
+		return
+	}
+	pl.driving = true
+	pl.pushed = 0
+	pl.dir = 1
+	pl.x = -plowWidth
+	if g.rng.Intn(2) == 0 {
+		pl.dir = -1
+		pl.x = float64(g.screenWidth)
+	}
+}
+
+// blade returns where the front of the blade is
+func (pl *Plow) blade() float64 {
+	if pl.dir < 0 {
+		return pl.x
+	}
+	return pl.x + plowWidth
+}
+
+// updatePlow sends the plow out every plow_every minutes and drives it
+// along, scraping up the snow on the ground in front of it
+func (g *Game) updatePlow(r *rand.Rand) {
+	pl := &g.plow
+	now := time.Now()
+
+	switch {
+	case !g.config.Plow:
+		pl.next = time.Time{}
+	case pl.next.IsZero():
+		pl.next = now.Add(minutes(g.config.PlowEvery))
+	case now.After(pl.next):
+		pl.next = time.Time{}
+		g.SendPlow()
+	}
+
+	p, ok := g.piles[groundPile]
+	if !pl.driving || !ok {
+		pl.driving = false
+		return
+	}
+
+	from := pl.blade()
+	pl.x += pl.dir * plowSpeed
+	to := pl.blade()
+	for x := min(from, to); x < max(from, to); x += pileColumnWidth {
+		if col := p.column(x); col >= 0 {
+			pl.pushed += p.depth[col] - p.dent[col]
+			p.depth[col] = 0
+			p.dent[col] = 0
+		}
+	}
+	if pl.x < -plowWidth || pl.x > float64(g.screenWidth) {
+		pl.driving = false
+		return
+	}
+
+	// Now and then a little snow tumbles off the top of the mound
+	if height := pl.mound(); height > 2 && r.Float64() < plowSpray {
+		life := 20 + r.Intn(20)
+		g.particles.Emit(Particle{
+			x:      to + pl.dir*height/2,
+			y:      p.y - height,
+			vx:     pl.dir * (1 + r.Float64()*2),
+			vy:     -r.Float64() * 2,
+			size:   1 + r.Float64()*2,
+			life:   life,
+			span:   life,
+			color:  g.flakeColor,
+			behave: sprayBehavior,
+		})
+	}
+}
+
+// mound returns how tall the snow pushed ahead of the blade stands
+func (pl *Plow) mound() float64 {
+	return min(math.Sqrt(pl.pushed)*plowMound, plowMoundMax)
+}
+
+// makePlow draws the silhouette of a small plow truck facing right, with
+// its blade out in front
+func makePlow() *ebiten.Image {
+	img := ebiten.NewImage(plowWidth, plowHeight)
+	c := color.White
+
+	vector.DrawFilledRect(img, 4, 24, 86, 16, c, true) // The body
+	vector.DrawFilledRect(img, 56, 6, 30, 20, c, true) // The cab
+	vector.DrawFilledRect(img, 70, 0, 6, 6, c, true)   // The light on its roof
+	vector.DrawFilledRect(img, 90, 30, 14, 4, c, true) // The arm holding the blade
+	vector.DrawFilledCircle(img, 22, 42, 9, c, true)   // The wheels
+	vector.DrawFilledCircle(img, 72, 42, 9, c, true)
+	vector.StrokeLine(img, 104, 18, 116, plowHeight-1, 5, c, true) // The blade, angled to throw the snow
+	vector.DrawFilledRect(img, 103, 16, 6, plowHeight-16, c, true)
+	return outlined(img, plowColor)
+}
+
+// drawPlow draws the plow on its way and the mound of snow ahead of it
+func (g *Game) drawPlow(screen *ebiten.Image) {
+	pl := &g.plow
+	if !pl.driving {
+		return
+	}
+	if plowSprite == nil {
+		plowSprite = makePlow()
+	}
+	bottom := float64(g.screenHeight)
+
+	if height := pl.mound(); height >= 1 {
+		x := pl.blade() + pl.dir*height*0.6
+		vector.DrawFilledCircle(screen, float32(x), float32(bottom), float32(height), g.flakeColor, true)
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	if pl.dir < 0 {
+		op.GeoM.Scale(-1, 1)
+		op.GeoM.Translate(plowWidth, 0)
+	}
+	op.GeoM.Translate(pl.x, bottom-plowHeight)
+	screen.DrawImage(plowSprite, op)
+}
+
+// drawSpray draws a bit of snow thrown off the plow's mound
+func drawSpray(g *Game, screen *ebiten.Image, p Particle) {
+	c := p.color
+	c.A = uint8(float64(c.A) * (1 - p.age()))
+	vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), float32(p.size/2), c, true)
+}
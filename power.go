@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/sys/windows"
+)
+
+// Constants for power notifications
+const (
+	WM_POWERBROADCAST               = 0x0218
+	PBT_APMPOWERSTATUSCHANGE        = 0x000A
+	PBT_POWERSETTINGCHANGE          = 0x8013
+	DEVICE_NOTIFY_WINDOW_HANDLE     = 0
+	AC_LINE_OFFLINE                 = 0
+	SYSTEM_STATUS_FLAG_POWER_SAVING = 1
+)
+
+// What to do on battery power or with battery saver on
+const (
+	batteryNormal   = "normal"   // Carry on as usual
+	batteryThrottle = "throttle" // Fewer flakes at a lower frame rate
+	batteryPause    = "pause"    // Stop the snow until plugged in again
+)
+
+// Ticks per second and share of the flakes kept while throttled
+const (
+	throttledTPS    = 30
+	throttledFlakes = 0.5
+)
+
+// Physics steps a second the motion of the snow is tuned for
+const physicsTPS = 60
+
+// Leeway given to the frame cap, so frames that arrive a little early on
+// the display's refresh aren't skipped
+const frameSlack = 2 * time.Millisecond
+
+// Notified when battery saver is switched on or off
+var guidPowerSavingStatus = windows.GUID{
+	Data1: 0xE00958C0, Data2: 0xC213, Data3: 0x4ACE,
+	Data4: [8]byte{0xAC, 0x77, 0xFE, 0xCC, 0xED, 0x2E, 0xEE, 0xA5},
+}
+
+// systemPowerStatus mirrors the Windows SYSTEM_POWER_STATUS structure
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// validateOnBattery checks the on_battery setting
+func validateOnBattery(mode string) error {
+	switch mode {
+	case batteryNormal, batteryThrottle, batteryPause:
+		return nil
+	}
+	return fmt.Errorf("on_battery must be %s, %s or %s, got %q", batteryNormal, batteryThrottle, batteryPause, mode)
+}
+
+// OnBattery reports whether the machine is running on battery or has battery saver on
+func OnBattery() bool {
+	var status systemPowerStatus
+	if ok, _, _ := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status))); ok == 0 {
+		return false
+	}
+	return status.ACLineStatus == AC_LINE_OFFLINE || status.SystemStatusFlag == SYSTEM_STATUS_FLAG_POWER_SAVING
+}
+
+// WatchPower tells the game when the machine switches between mains and
+// battery power or battery saver changes. Call it from the message window's
+// setup function.
+func WatchPower(w *MessageWindow, game *Game) {
+	if handle, _, err := procRegisterPowerSettingNotification.Call(w.hwnd,
+		uintptr(unsafe.Pointer(&guidPowerSavingStatus)), DEVICE_NOTIFY_WINDOW_HANDLE); handle == 0 {
+		log.Println("Could not watch battery saver:", err)
+	}
+
+	w.Handle(WM_POWERBROADCAST, func(wParam, lParam uintptr) uintptr {
+		if wParam == PBT_APMPOWERSTATUSCHANGE || wParam == PBT_POWERSETTINGCHANGE {
+			onBattery := OnBattery()
+			game.Post(func(g *Game) { g.SetOnBattery(onBattery) })
+		}
+		return 1
+	})
+
+	onBattery := OnBattery()
+	game.Post(func(g *Game) { g.SetOnBattery(onBattery) })
+}
+
+// SetOnBattery records the power source and applies the on_battery setting
+func (g *Game) SetOnBattery(onBattery bool) {
+	g.onBattery = onBattery
+	g.applyThrottling()
+}
+
+// applyThrottling throttles or pauses the snow as the on_battery and
+// low_cost_remote settings ask
+func (g *Game) applyThrottling() {
+	mode := batteryNormal
+	if g.onBattery {
+		mode = g.config.OnBattery
+	}
+
+	g.throttled = mode == batteryThrottle || g.remote && g.config.LowCostRemote
+	if mode == batteryPause {
+		g.Pause(pausedByBattery)
+	} else {
+		g.Resume(pausedByBattery)
+	}
+	g.resizeFlakes()
+	ebiten.SetTPS(g.tps())
+}
+
+// tps returns the ticks per second to run at in the current state
+func (g *Game) tps() int {
+	switch {
+	case g.paused != 0:
+		return pausedTPS
+	case g.throttled || g.quality >= qualitySlow:
+		return throttledTPS
+	}
+	return g.config.TPS
+}
+
+// stepRate returns how many physics steps make a second. The physics is
+// tuned for 60 steps a second and keeps that pace at any tick rate, except
+// while throttled, when the snow is meant to slow down along with the ticks.
+func (g *Game) stepRate() int {
+	if g.throttled || g.quality >= qualitySlow {
+		return throttledTPS
+	}
+	return physicsTPS
+}
+
+// frameDue reports whether the frame cap allows a frame to be drawn now.
+// The screen is not cleared between frames, so the last one stays up in
+// the meantime.
+func (g *Game) frameDue() bool {
+	fps := g.config.FPS
+	if fps <= 0 || g.overlayOpen() {
+		return true
+	}
+	now := time.Now()
+	if now.Before(g.nextFrame.Add(-frameSlack)) {
+		return false
+	}
+	interval := time.Second / time.Duration(fps)
+	g.nextFrame = g.nextFrame.Add(interval)
+	if g.nextFrame.Before(now) {
+		g.nextFrame = now.Add(interval)
+	}
+	return true
+}
+
+// flakeCount returns how many flakes should be falling
+func (g *Game) flakeCount() int {
+	if g.config.Stars == starsAlone {
+		return 0
+	}
+	flakes := float64(g.config.Flakes)
+	if d := g.config.Density; d > 0 {
+		flakes = d * float64(g.screenWidth*g.screenHeight) / 1e6
+	}
+	flakes *= g.weather.level * g.blizzardFlakeScale() * qualityFlakes[g.quality]
+	if g.throttled {
+		flakes *= throttledFlakes
+	}
+	return int(flakes)
+}
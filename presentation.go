@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Constants for detecting presentations and projectors
+const (
+	QUNS_PRESENTATION_MODE       = 4 // Presentation settings are on or a slideshow is running
+	QDC_DATABASE_CURRENT         = 0x00000004
+	DISPLAYCONFIG_TOPOLOGY_CLONE = 0x00000002 // Win+P "Duplicate"
+	displayConfigPathInfoSize    = 72         // sizeof(DISPLAYCONFIG_PATH_INFO)
+	displayConfigModeInfoSize    = 64         // sizeof(DISPLAYCONFIG_MODE_INFO)
+)
+
+// WatchPresentation pauses the game during presentations and while the
+// screen is duplicated to a projector, checking every couple of seconds
+func WatchPresentation(game *Game) {
+	ticker := time.NewTicker(2 * time.Second)
+	for range ticker.C {
+		presenting := PresentationActive()
+		game.Post(func(g *Game) {
+			if presenting && g.config.PauseOnPresentation {
+				g.Pause(pausedByPresentation)
+			} else {
+				g.Resume(pausedByPresentation)
+			}
+		})
+	}
+}
+
+// PresentationActive reports whether a presentation is running or the
+// desktop is duplicated onto a projector. "Second screen only" doesn't
+// count, as that is how a docked laptop with its lid closed runs.
+func PresentationActive() bool {
+	var state uintptr
+	if ret, _, _ := procSHQueryUserNotificationState.Call(uintptr(unsafe.Pointer(&state))); ret == 0 && state == QUNS_PRESENTATION_MODE {
+		return true
+	}
+
+	return displayTopology() == DISPLAYCONFIG_TOPOLOGY_CLONE
+}
+
+// displayTopology returns the Win+P display topology, or 0 if it is unknown
+func displayTopology() uint32 {
+	var paths, modes uint32
+	if ret, _, _ := procGetDisplayConfigBufferSizes.Call(QDC_DATABASE_CURRENT,
+		uintptr(unsafe.Pointer(&paths)), uintptr(unsafe.Pointer(&modes))); ret != 0 || paths == 0 {
+		return 0
+	}
+
+	pathInfo := make([]byte, paths*displayConfigPathInfoSize)
+	modeInfo := make([]byte, max(modes, 1)*displayConfigModeInfoSize)
+	var topology uint32
+	if ret, _, _ := procQueryDisplayConfig.Call(QDC_DATABASE_CURRENT,
+		uintptr(unsafe.Pointer(&paths)), uintptr(unsafe.Pointer(&pathInfo[0])),
+		uintptr(unsafe.Pointer(&modes)), uintptr(unsafe.Pointer(&modeInfo[0])),
+		uintptr(unsafe.Pointer(&topology))); ret != 0 {
+		return 0
+	}
+	return topology
+}
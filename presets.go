@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Preset bundles the settings that control the overall intensity of the snow
+type Preset struct {
+	Flakes   int     `toml:"flakes" json:"flakes"`
+	SpeedMin float64 `toml:"speed_min" json:"speed_min"`
+	SpeedMax float64 `toml:"speed_max" json:"speed_max"`
+	Wind     float64 `toml:"wind" json:"wind"`
+	Opacity  float64 `toml:"opacity" json:"opacity"`
+}
+
+// Built-in presets, available unless the config file defines one with the same name
+var builtinPresets = map[string]Preset{
+	"calm": {
+		Flakes:   120,
+		SpeedMin: 2.0,
+		SpeedMax: 6.0,
+		Wind:     0.3,
+		Opacity:  0.8,
+	},
+	"flurry": {
+		Flakes:   300,
+		SpeedMin: 6.0,
+		SpeedMax: 16.0,
+		Wind:     0.8,
+		Opacity:  1.0,
+	},
+	"blizzard": {
+		Flakes:   1200,
+		SpeedMin: 12.0,
+		SpeedMax: 28.0,
+		Wind:     4.0,
+		Opacity:  1.0,
+	},
+}
+
+// LookupPreset finds a preset by name, preferring the user's own definitions
+func (c *Config) LookupPreset(name string) (Preset, bool) {
+	if p, ok := c.Presets[name]; ok {
+		return p, true
+	}
+	p, ok := builtinPresets[name]
+	return p, ok
+}
+
+// PresetNames returns the names of all available presets in sorted order
+func (c *Config) PresetNames() []string {
+	var names []string
+	for name := range builtinPresets {
+		names = append(names, name)
+	}
+	for name := range c.Presets {
+		if _, ok := builtinPresets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyPreset overwrites the bundled settings with those of the named preset
+func (c *Config) ApplyPreset(name string) error {
+	p, ok := c.LookupPreset(name)
+	if !ok {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+
+	c.Preset = name
+	c.SetFlakes(p.Flakes)
+	c.SpeedMin = p.SpeedMin
+	c.SpeedMax = p.SpeedMax
+	c.Wind = p.Wind
+	c.Opacity = p.Opacity
+	return nil
+}
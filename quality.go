@@ -0,0 +1,87 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Steps the quality governor drops through as the machine falls behind,
+// each lighter than the last
+const (
+	qualityFull    = iota // Everything as configured
+	qualityFewer          // Fewer flakes
+	qualityNoFog          // Fewer flakes still, and no fog
+	qualityNoPiles        // Snow no longer piles up
+	qualitySlow           // The tick rate drops as it does when throttled
+)
+
+// Names of the quality steps, shown in the HUD
+var qualityNames = [...]string{"full", "fewer flakes", "no fog", "no piles", "slow"}
+
+// Share of the flakes kept at each quality step
+var qualityFlakes = [...]float64{1, 0.7, 0.5, 0.4, 0.3}
+
+// How the governor judges the load
+const (
+	qualityLow     = 0.9              // Share of the tick rate below which the machine is falling behind
+	qualityHigh    = 0.98             // Share of the tick rate above which the machine is keeping up
+	qualityRoom    = 0.6              // Share of the time spent in Update and Draw below which there is room for more
+	qualitySmooth  = 0.05             // How quickly the measured frame cost follows each tick's
+	qualitySettle  = 3 * time.Second  // How long the measured rate is given to settle before dropping a step
+	qualityRecover = 20 * time.Second // How long there must be room before a step is restored
+)
+
+// timeFrame adds the time since start to the time spent in Update and
+// Draw. Defer it at the top of each.
+func (g *Game) timeFrame(start time.Time) {
+	g.busy += time.Since(start)
+}
+
+// updateQuality lowers the quality a step at a time while the game can't
+// keep up with its tick rate, and restores it once Update and Draw have
+// been leaving plenty of each tick's time spare for a while. Keeping up
+// alone isn't enough to restore a step, as the lower step is what let it.
+func (g *Game) updateQuality() {
+	if !g.config.AdaptiveQuality {
+		if g.quality != qualityFull {
+			g.setQuality(qualityFull)
+		}
+		return
+	}
+
+	// Measure the share of the time since the last tick spent working
+	now := time.Now()
+	if elapsed := now.Sub(g.busyAt); !g.busyAt.IsZero() && elapsed > 0 {
+		g.frameCost += (float64(g.busy)/float64(elapsed) - g.frameCost) * qualitySmooth
+	}
+	g.busy, g.busyAt = 0, now
+	if g.frameCost > qualityRoom {
+		g.crowdedAt = now
+	}
+
+	// The measured rate lags behind, so start timing afresh whenever the
+	// tick rate changes, as it does on pausing or throttling
+	if tps := g.tps(); tps != g.qualityTPS {
+		g.qualityTPS = tps
+		g.qualityAt = now
+		return
+	}
+
+	since := now.Sub(g.qualityAt)
+	load := ebiten.ActualTPS() / float64(g.qualityTPS)
+	switch {
+	case load < qualityLow && since > qualitySettle && g.quality < qualitySlow:
+		g.setQuality(g.quality + 1)
+	case load > qualityHigh && since > qualityRecover && now.Sub(g.crowdedAt) > qualityRecover && g.quality > qualityFull:
+		g.setQuality(g.quality - 1)
+	}
+}
+
+// setQuality switches to a quality step
+func (g *Game) setQuality(quality int) {
+	g.quality = quality
+	g.qualityAt = time.Now()
+	g.resizeFlakes()
+	ebiten.SetTPS(g.tps())
+}
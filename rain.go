@@ -0,0 +1,57 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Shape and splash of the rain
+const (
+	rainSpeed     = 3.0  // Times faster than snow drops fall
+	rainStreak    = 1.5  // Length of a drop in frames of movement
+	splashDrops   = 2    // Fewest droplets thrown up where a drop lands; sometimes one more
+	splashSpeed   = 3.0  // Fastest a droplet is thrown up, in pixels per frame
+	splashSpread  = 1.5  // Fastest a droplet is thrown sideways, in pixels per frame
+	splashSizeMax = 1.5  // Largest droplet in pixels
+	splashGravity = 0.25 // Pull on a droplet in pixels per frame per frame
+	splashLifeMin = 10   // Fewest frames a droplet lasts
+	splashLifeMax = 22   // Most frames a droplet lasts
+)
+
+// Droplets bounce up from a splash and fall back, fading as they go
+var splashBehavior = &Behavior{gravity: splashGravity, drag: 1, draw: drawDroplet}
+
+// drawRaindrop draws a drop as a streak along the way it is moving
+func drawRaindrop(g *Game, screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	x0, y0 := f.x-f.vx*rainStreak, f.y-f.vy*rainStreak
+	vector.StrokeLine(screen, float32(x0), float32(y0), float32(f.x), float32(f.y), float32(max(f.size/2, 1)), c, true)
+}
+
+// splash throws up a few droplets where a drop lands
+func splash(g *Game, f *Snowflake) {
+	r := g.rng
+	for range splashDrops + r.Intn(2) {
+		life := splashLifeMin + r.Intn(splashLifeMax-splashLifeMin)
+		x, y, vx, vy := g.fallToScreen(f.x, f.y-1, (r.Float64()*2-1)*splashSpread, -r.Float64()*splashSpeed)
+		g.particles.Emit(Particle{
+			x:      x,
+			y:      y,
+			vx:     vx,
+			vy:     vy,
+			size:   min(f.size/2, splashSizeMax),
+			life:   life,
+			span:   life,
+			color:  g.flakeColor,
+			behave: splashBehavior,
+		})
+	}
+}
+
+// drawDroplet draws a droplet of a splash, fading as it falls back
+func drawDroplet(g *Game, screen *ebiten.Image, p Particle) {
+	c := p.color
+	c.A = uint8(float64(c.A) * (1 - p.age()))
+	vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), float32(max(p.size/2, 0.5)), c, true)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry key holding the user's settings, under HKEY_CURRENT_USER
+const registryKey = `Software\winsnow`
+
+// LoadRegistry overlays any settings stored in the registry onto cfg.
+// A missing key is not an error. The registry is not used in portable mode.
+func LoadRegistry(cfg *Config) error {
+	if portable {
+		return nil
+	}
+
+	k, err := registry.OpenKey(registry.CURRENT_USER, registryKey, registry.QUERY_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer k.Close()
+
+	forEachSetting(cfg, func(name string, v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Int:
+			if n, _, err := k.GetIntegerValue(name); err == nil {
+				v.SetInt(int64(n))
+			}
+		case reflect.Bool:
+			if n, _, err := k.GetIntegerValue(name); err == nil {
+				v.SetBool(n != 0)
+			}
+		case reflect.Float64:
+			// Floats have no registry type of their own, so they are stored as strings
+			if s, _, err := k.GetStringValue(name); err == nil {
+				if f, err := strconv.ParseFloat(s, 64); err == nil {
+					v.SetFloat(f)
+				}
+			}
+		case reflect.String:
+			if s, _, err := k.GetStringValue(name); err == nil {
+				v.SetString(s)
+			}
+		}
+	})
+	return nil
+}
+
+// HasRegistrySettings reports whether settings have ever been saved to the registry
+func HasRegistrySettings() bool {
+	if portable {
+		return false
+	}
+
+	k, err := registry.OpenKey(registry.CURRENT_USER, registryKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	k.Close()
+	return true
+}
+
+// SaveRegistry stores cfg in the registry, except in portable mode
+func SaveRegistry(cfg Config) error {
+	if portable {
+		return nil
+	}
+
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, registryKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	forEachSetting(&cfg, func(name string, v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Int:
+			err = errors.Join(err, k.SetQWordValue(name, uint64(v.Int())))
+		case reflect.Bool:
+			var n uint32
+			if v.Bool() {
+				n = 1
+			}
+			err = errors.Join(err, k.SetDWordValue(name, n))
+		case reflect.Float64:
+			err = errors.Join(err, k.SetStringValue(name, strconv.FormatFloat(v.Float(), 'g', -1, 64)))
+		case reflect.String:
+			err = errors.Join(err, k.SetStringValue(name, v.String()))
+		}
+	})
+	return err
+}
+
+// forEachSetting calls fn for every plain setting in cfg, named by its TOML key.
+// Tables such as the user presets are skipped.
+func forEachSetting(cfg *Config, fn func(name string, v reflect.Value)) {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fn(name, v.Field(i))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Set by GetSystemMetrics while the session is shown over Remote Desktop
+const SM_REMOTESESSION = 0x1000
+
+// Words in the BIOS vendor or product name that give away a virtual machine
+var virtualMachineVendors = []string{"vmware", "virtualbox", "qemu", "kvm", "xen", "parallels", "virtual machine"}
+
+// IsRemoteSession reports whether the desktop is being shown over Remote Desktop
+func IsRemoteSession() bool {
+	remote, _, _ := procGetSystemMetrics.Call(SM_REMOTESESSION)
+	return remote != 0
+}
+
+// IsVirtualMachine reports whether winsnow is running in a virtual machine,
+// judging by the BIOS details Windows records
+func IsVirtualMachine() bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\BIOS`, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer k.Close()
+
+	for _, name := range []string{"SystemManufacturer", "SystemProductName"} {
+		value, _, err := k.GetStringValue(name)
+		if err != nil {
+			continue
+		}
+		value = strings.ToLower(value)
+		for _, vendor := range virtualMachineVendors {
+			if strings.Contains(value, vendor) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetRemote records whether the snow is drawn in software over Remote
+// Desktop or in a virtual machine, where it is much more expensive
+func (g *Game) SetRemote(remote bool) {
+	g.remote = remote
+	g.applyThrottling()
+}
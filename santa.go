@@ -0,0 +1,219 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How the sleigh flies and sparkles
+const (
+	sleighWidth    = 190  // Width of the sleigh and reindeer in pixels
+	sleighHeight   = 44   // Height of the sleigh and reindeer in pixels
+	sleighSpeed    = 3.0  // Pixels per frame the sleigh flies
+	sleighBob      = 12.0 // Pixels the sleigh rises and dips as it flies
+	sleighAltitude = 0.12 // How far down the screen it flies, as a share of the height
+	sleighEveryMin = 10   // Fewest minutes between flybys over Christmas
+	sleighEveryMax = 40   // Most minutes between flybys over Christmas
+	sparklesPer    = 2    // Sparkles shed each frame
+	sparkleLife    = 70   // Most frames a sparkle glitters for
+)
+
+// Color of the sleigh and reindeer against the sky, and of the pale edge
+// that keeps silhouettes like it visible on a dark backdrop
+var (
+	sleighColor  = color.NRGBA{0x1c, 0x1c, 0x2c, 0xff}
+	outlineColor = color.NRGBA{0xe4, 0xec, 0xf6, 0xd0}
+)
+
+// Sparkles drift slowly down behind the sleigh, glittering as they fade
+var sparkleBehavior = &Behavior{gravity: 0.01, drag: 1, draw: drawSparkle}
+
+// The sleigh and reindeer facing right, made on first use
+var sleighSprite *ebiten.Image
+
+// Sleigh is Santa's sleigh flying across the top of the screen, shedding
+// sparkles over the snow
+type Sleigh struct {
+	flying   bool
+	x        float64   // Left edge of the sprite
+	dir      float64   // 1 flying right or -1 flying left
+	frame    int       // Frames since the flyby started
+	next     time.Time // When the next flyby over Christmas is due, zero if none is
+	sparkles Emitter   // Sparkles trailing off the back of the sleigh
+}
+
+// christmas reports whether t falls on Christmas Eve or Christmas Day
+func christmas(t time.Time) bool {
+	return t.Month() == time.December && (t.Day() == 24 || t.Day() == 25)
+}
+
+// FlySleigh sends the sleigh across the screen now, unless it is already on its way
+func (g *Game) FlySleigh() {
+	s := &g.sleigh
+	if s.flying {
+		return
+	}
+	s.flying = true
+	s.frame = 0
+	s.dir = 1
+	s.x = -sleighWidth
+	if g.rng.Intn(2) == 0 {
+		s.dir = -1
+		s.x = float64(g.screenWidth)
+	}
+	s.sparkles = Emitter{rate: sparklesPer, spawn: func(r *rand.Rand) Particle {
+		bx, by := s.back(g.screenHeight)
+		life := sparkleLife/2 + r.Intn(sparkleLife/2)
+		return Particle{
+			x:      bx + (r.Float64()*2-1)*4,
+			y:      by + (r.Float64()*2-1)*4,
+			vx:     -s.dir * r.Float64() * 0.5,
+			vy:     r.Float64() * 0.5,
+			life:   life,
+			span:   life,
+			color:  fireworkPalette[1], // Gold
+			behave: sparkleBehavior,
+		}
+	}}
+	g.notify("Ho ho ho!", "Santa's sleigh is flying over.")
+}
+
+// updateSleigh sends the sleigh over now and then at Christmas and flies
+// it along its way, leaving sparkles behind
+func (g *Game) updateSleigh(r *rand.Rand) {
+	s := &g.sleigh
+	now := time.Now()
+
+	switch {
+	case !g.config.Sleigh || !christmas(now):
+		s.next = time.Time{}
+	case s.next.IsZero():
+		wait := sleighEveryMin + r.Float64()*(sleighEveryMax-sleighEveryMin)
+		s.next = now.Add(minutes(wait))
+	case now.After(s.next):
+		s.next = time.Time{}
+		g.FlySleigh()
+	}
+
+	if s.flying {
+		s.frame++
+		s.x += s.dir * sleighSpeed
+		if s.x < -sleighWidth || s.x > float64(g.screenWidth) {
+			s.flying = false
+		}
+
+		s.sparkles.Run(&g.particles, r)
+	}
+}
+
+// top returns how far down the screen the top of the sleigh is, bobbing as it flies
+func (s *Sleigh) top(screenHeight int) float64 {
+	return float64(screenHeight)*sleighAltitude + math.Sin(float64(s.frame)*0.04)*sleighBob
+}
+
+// back returns where the back of the sleigh is, where the sparkles come from
+func (s *Sleigh) back(screenHeight int) (float64, float64) {
+	x := s.x + 8
+	if s.dir < 0 {
+		x = s.x + sleighWidth - 8
+	}
+	return x, s.top(screenHeight) + sleighHeight - 8
+}
+
+// makeSleigh draws the silhouette of Santa in his sleigh behind four
+// galloping reindeer, facing right
+func makeSleigh() *ebiten.Image {
+	img := ebiten.NewImage(sleighWidth, sleighHeight)
+	c := color.White
+
+	// The sleigh, with a curled runner underneath and Santa sitting up in it
+	vector.DrawFilledRect(img, 6, 22, 34, 12, c, true)
+	vector.DrawFilledRect(img, 4, 16, 8, 10, c, true)
+	vector.StrokeLine(img, 2, 39, 44, 39, 2, c, true)
+	vector.StrokeLine(img, 44, 39, 48, 33, 2, c, true)
+	vector.StrokeLine(img, 12, 34, 12, 39, 2, c, true)
+	vector.StrokeLine(img, 34, 34, 34, 39, 2, c, true)
+	vector.DrawFilledCircle(img, 22, 17, 7, c, true)   // Santa
+	vector.DrawFilledCircle(img, 24, 8, 4.5, c, true)  // His head
+	vector.StrokeLine(img, 24, 4, 19, 1, 3, c, true)   // His hat
+	vector.DrawFilledRect(img, 26, 12, 14, 8, c, true) // The sack
+	vector.StrokeLine(img, 40, 26, 70, 22, 1, c, true) // The reins
+
+	// The reindeer in pairs, one behind the other
+	for i := range 4 {
+		x := 62 + float32(i)*32
+		y := float32(18)
+		if i%2 == 1 {
+			y -= 3 // Galloping out of step
+		}
+		vector.DrawFilledRect(img, x, y, 18, 7, c, true)
+		vector.DrawFilledCircle(img, x, y+3.5, 3.5, c, true)
+		vector.DrawFilledCircle(img, x+18, y+3.5, 3.5, c, true)
+		vector.StrokeLine(img, x+18, y+2, x+23, y-5, 3, c, true) // Neck
+		vector.DrawFilledCircle(img, x+25, y-6, 3, c, true)      // Head
+		vector.StrokeLine(img, x+23, y-8, x+20, y-14, 1, c, true)
+		vector.StrokeLine(img, x+21, y-11, x+18, y-12, 1, c, true)
+		vector.StrokeLine(img, x+2, y+6, x-4, y+14, 1.5, c, true) // Legs stretched back
+		vector.StrokeLine(img, x+5, y+6, x, y+15, 1.5, c, true)
+		vector.StrokeLine(img, x+15, y+6, x+22, y+13, 1.5, c, true) // And forward
+		vector.StrokeLine(img, x+18, y+6, x+24, y+11, 1.5, c, true)
+		if i > 0 {
+			vector.StrokeLine(img, x-12, y+3, x-3, y+3, 1, c, true) // Harness
+		}
+	}
+	return outlined(img, sleighColor)
+}
+
+// outlined fills a white silhouette with fill and rings it with a thin pale
+// edge, so it shows against a dark desktop as well as a light one
+func outlined(shape *ebiten.Image, fill color.NRGBA) *ebiten.Image {
+	img := ebiten.NewImage(shape.Bounds().Dx(), shape.Bounds().Dy())
+	for _, d := range [8][2]float64{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}} {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(d[0]*1.5, d[1]*1.5)
+		op.ColorScale.ScaleWithColor(outlineColor)
+		img.DrawImage(shape, op)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.ScaleWithColor(fill)
+	img.DrawImage(shape, op)
+	return img
+}
+
+// drawSparkle draws a sparkle shed by the sleigh, glittering as it fades
+func drawSparkle(g *Game, screen *ebiten.Image, p Particle) {
+	bright := 1 - p.age()
+	twinkle := 0.6 + 0.4*math.Sin(float64(p.life)*0.7)
+	c := p.color
+	c.A = uint8(255 * bright * twinkle)
+	vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), float32(0.6+bright), c, true)
+}
+
+// drawSleigh draws the sleigh on its way
+func (g *Game) drawSleigh(screen *ebiten.Image) {
+	s := &g.sleigh
+	if !s.flying {
+		return
+	}
+
+	if sleighSprite == nil {
+		sleighSprite = makeSleigh()
+	}
+	op := &ebiten.DrawImageOptions{}
+	if s.dir < 0 {
+		op.GeoM.Scale(-1, 1)
+		op.GeoM.Translate(sleighWidth, 0)
+	}
+	// Nose up a little as it climbs and down as it dips
+	tilt := math.Cos(float64(s.frame)*0.04) * 0.04 * s.dir
+	op.GeoM.Translate(-sleighWidth/2, -sleighHeight/2)
+	op.GeoM.Rotate(tilt)
+	op.GeoM.Translate(sleighWidth/2, sleighHeight/2)
+	op.GeoM.Translate(s.x, s.top(g.screenHeight))
+	screen.DrawImage(sleighSprite, op)
+}
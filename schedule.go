@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Schedule limits the snow to certain hours and days.
+// Outside of it the simulation is suspended rather than exiting.
+type Schedule struct {
+	From  string   `toml:"from" json:"from"`   // Start of the active hours as HH:MM, empty for all day
+	Until string   `toml:"until" json:"until"` // End of the active hours as HH:MM; may be earlier than From to span midnight
+	Days  []string `toml:"days" json:"days"`   // Active days (mon..sun, weekdays, weekends), empty for every day
+}
+
+// Validate checks that the times and days can be understood
+func (s *Schedule) Validate() error {
+	if (s.From == "") != (s.Until == "") {
+		return fmt.Errorf("schedule needs both from and until, or neither")
+	}
+	for _, t := range []string{s.From, s.Until} {
+		if _, err := parseClock(t); t != "" && err != nil {
+			return err
+		}
+	}
+	for _, d := range s.Days {
+		if _, ok := scheduleDays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("schedule: unknown day %q", d)
+		}
+	}
+	return nil
+}
+
+// Active reports whether the snow should be running at t
+func (s *Schedule) Active(t time.Time) bool {
+	if len(s.Days) > 0 {
+		today := false
+		for _, d := range s.Days {
+			for _, wd := range scheduleDays[strings.ToLower(d)] {
+				today = today || wd == t.Weekday()
+			}
+		}
+		if !today {
+			return false
+		}
+	}
+
+	if s.From == "" {
+		return true
+	}
+	from, _ := parseClock(s.From)
+	until, _ := parseClock(s.Until)
+	now := t.Hour()*60 + t.Minute()
+	if from <= until {
+		return now >= from && now < until
+	}
+	// The window spans midnight
+	return now >= from || now < until
+}
+
+// Day names accepted in the schedule
+var scheduleDays = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// parseClock turns HH:MM into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("schedule: time %q must be in HH:MM form", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// releaseGraphics frees the textures, shaders and vertex buffers the snow is
+// drawn with while it is suspended. Most are made again on first use once
+// it's back; restoreGraphics makes the rest.
+func (g *Game) releaseGraphics() {
+	images := []**ebiten.Image{
+		&flakeAtlas, &crystalAtlas, &dotSprite, &trailPixel, &fallLayer,
+		&confettiSprite, &glowSprite, &leafSprite, &petalSprite, &sleighSprite, &plowSprite,
+		&groundFogGradient, &frostPattern, &g.moon.image,
+	}
+	for _, img := range images {
+		if *img != nil {
+			(*img).Deallocate()
+			*img = nil
+		}
+	}
+	for _, textures := range []*[]*ebiten.Image{&g.fog.textures, &g.moon.clouds.textures} {
+		for _, t := range *textures {
+			t.Deallocate()
+		}
+		*textures = nil
+	}
+	for _, s := range g.customSprites {
+		s.image.Deallocate()
+	}
+	for _, shader := range []**ebiten.Shader{&snowShader, &auroraShader, &frostShader} {
+		if *shader != nil {
+			(*shader).Deallocate()
+			*shader = nil
+		}
+	}
+	flakeSprites = nil
+	g.crystals = nil
+	g.customSprites = nil
+	g.batch = Batch{}
+	g.soft = SoftFlakes{}
+}
+
+// restoreGraphics makes the textures again that aren't made on first use,
+// when the snow comes back from being suspended
+func (g *Game) restoreGraphics() {
+	g.crystals = GenerateCrystals(g.rng)
+	g.updateCustomSprites()
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Pipe the supervisor listens on for "winsnow service" commands
+const servicePipe = "service"
+
+// How long the supervisor waits before restarting a crashed renderer,
+// doubling after each crash in a row up to the maximum
+const (
+	restartDelay    = 2 * time.Second
+	maxRestartDelay = time.Minute
+)
+
+// Run the renderer without a console window of its own
+const CREATE_NO_WINDOW = 0x08000000
+
+// Supervisor keeps a renderer process running, restarting it if it crashes
+type Supervisor struct {
+	mu       sync.Mutex
+	args     []string  // Flags passed on to the renderer
+	cmd      *exec.Cmd // The running renderer, nil if there is none
+	wanted   bool      // Whether the renderer should be running
+	crashes  int       // Crashes in a row, for backing off restarts
+	restarts int       // Total restarts, for status
+}
+
+// RunService runs the supervisor until told to exit. args are passed on to the renderer.
+func RunService(args []string) error {
+	ln, err := ListenPipe(servicePipe)
+	if err != nil {
+		return fmt.Errorf("the winsnow service is already running (%w)", err)
+	}
+	defer ln.Close()
+
+	// Started at login the supervisor gets a console window of its own,
+	// which there is no reason to keep on screen
+	if ownConsole() {
+		procFreeConsole.Call()
+	}
+
+	s := &Supervisor{args: args}
+	s.Start()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		if s.handle(conn) {
+			return nil
+		}
+	}
+}
+
+// handle answers one command, returning true if the supervisor should exit
+func (s *Supervisor) handle(conn io.ReadWriteCloser) bool {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch cmd := strings.TrimSpace(line); cmd {
+	case "start":
+		s.Start()
+		fmt.Fprintln(conn, "started")
+	case "stop":
+		s.Stop()
+		fmt.Fprintln(conn, "stopped")
+	case "status":
+		fmt.Fprintln(conn, s.Status())
+	case "exit":
+		s.Stop()
+		fmt.Fprintln(conn, "exiting")
+		return true
+	default:
+		fmt.Fprintf(conn, "error: unknown command %q\n", cmd)
+	}
+	return false
+}
+
+// Start launches the renderer unless it is already running
+func (s *Supervisor) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wanted = true
+	s.crashes = 0
+	if s.cmd == nil {
+		s.launch()
+	}
+}
+
+// Stop asks the renderer to quit and keeps it from being restarted
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	s.wanted = false
+	running := s.cmd != nil
+	s.mu.Unlock()
+
+	if running {
+		if _, err := SendCommand("quit"); err != nil {
+			log.Println("Could not stop the renderer:", err)
+		}
+	}
+}
+
+// Status describes the renderer's state in one line
+func (s *Supervisor) Status() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd == nil {
+		return fmt.Sprintf("stopped, %d restarts", s.restarts)
+	}
+	return fmt.Sprintf("running as process %d, %d restarts", s.cmd.Process.Pid, s.restarts)
+}
+
+// LaunchService starts the supervisor in the background, which starts a
+// renderer of its own
+func LaunchService() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{"service"}
+	if portable {
+		args = append(args, "-portable")
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: CREATE_NO_WINDOW}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// launch starts a renderer process. Call it with s.mu held.
+func (s *Supervisor) launch() {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Println("Could not start the renderer:", err)
+		return
+	}
+
+	cmd := exec.Command(exe, append([]string{"run"}, s.args...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: CREATE_NO_WINDOW}
+	if err := cmd.Start(); err != nil {
+		log.Println("Could not start the renderer:", err)
+		s.restartLater()
+		return
+	}
+	s.cmd = cmd
+	go s.wait(cmd)
+}
+
+// wait waits for the renderer to exit and restarts it if it crashed. A
+// clean exit, such as Exit in the tray menu, means the user stopped it.
+func (s *Supervisor) wait(cmd *exec.Cmd) {
+	err := cmd.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cmd = nil
+
+	if err == nil {
+		s.wanted = false
+		return
+	}
+	log.Println("Renderer stopped:", err)
+	if s.wanted {
+		s.restartLater()
+	}
+}
+
+// restartLater launches the renderer again after a delay that grows with
+// each crash in a row. Call it with s.mu held.
+func (s *Supervisor) restartLater() {
+	delay := restartDelay << min(s.crashes, 5)
+	delay = min(delay, maxRestartDelay)
+	s.crashes++
+
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.wanted && s.cmd == nil {
+			s.restarts++
+			s.launch()
+		}
+	})
+}
+
+// ownConsole reports whether this process is the only one attached to its
+// console, meaning the console was created for it rather than inherited
+func ownConsole() bool {
+	var pids [2]uint32
+	count, _, _ := procGetConsoleProcessList.Call(uintptr(unsafe.Pointer(&pids[0])), uintptr(len(pids)))
+	return count == 1
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"time"
+	"unsafe"
+)
+
+// Constants for session and screensaver notifications
+const (
+	WM_WTSSESSION_CHANGE      = 0x02B1
+	NOTIFY_FOR_THIS_SESSION   = 0
+	WTS_CONSOLE_CONNECT       = 0x1
+	WTS_CONSOLE_DISCONNECT    = 0x2
+	WTS_REMOTE_CONNECT        = 0x3
+	WTS_REMOTE_DISCONNECT     = 0x4
+	WTS_SESSION_LOCK          = 0x7
+	WTS_SESSION_UNLOCK        = 0x8
+	SPI_GETSCREENSAVERRUNNING = 0x0072
+)
+
+// WatchSession pauses the game while the workstation is locked or the
+// session is disconnected. Call it from the message window's setup function.
+func WatchSession(w *MessageWindow, game *Game) {
+	if ok, _, err := procWTSRegisterSessionNotification.Call(w.hwnd, NOTIFY_FOR_THIS_SESSION); ok == 0 {
+		log.Println("Could not watch for the session being locked:", err)
+		return
+	}
+
+	w.Handle(WM_WTSSESSION_CHANGE, func(wParam, lParam uintptr) uintptr {
+		switch wParam {
+		case WTS_SESSION_LOCK, WTS_CONSOLE_DISCONNECT, WTS_REMOTE_DISCONNECT:
+			game.Post(func(g *Game) { g.Pause(pausedBySession) })
+		case WTS_SESSION_UNLOCK, WTS_CONSOLE_CONNECT, WTS_REMOTE_CONNECT:
+			// Reconnecting may switch between the console and Remote Desktop
+			remote := IsRemoteSession() || IsVirtualMachine()
+			game.Post(func(g *Game) {
+				g.SetRemote(remote)
+				g.Resume(pausedBySession)
+			})
+		}
+		return 0
+	})
+}
+
+// WatchScreensaver pauses the game while the screensaver runs. Windows
+// doesn't announce the screensaver to background windows, so it is
+// checked once a second.
+func WatchScreensaver(game *Game) {
+	ticker := time.NewTicker(1 * time.Second)
+	for range ticker.C {
+		var running int32
+		procSystemParametersInfo.Call(SPI_GETSCREENSAVERRUNNING, 0, uintptr(unsafe.Pointer(&running)), 0)
+		game.Post(func(g *Game) {
+			if running != 0 {
+				g.Pause(pausedByScreensaver)
+			} else {
+				g.Resume(pausedByScreensaver)
+			}
+		})
+	}
+}
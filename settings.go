@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Layout of the settings panel
+const (
+	settingsX       = 40
+	settingsY       = 40
+	settingsWidth   = 360
+	settingsPadding = 16
+	sliderRowHeight = 44
+	sliderHeight    = 8
+)
+
+// settingsSlider is one adjustable value in the settings overlay
+type settingsSlider struct {
+	label    string
+	min, max float64
+	get      func(*Config) float64
+	set      func(*Config, float64)
+}
+
+var settingsSliders = []settingsSlider{
+	{
+		label: "Intensity",
+		min:   0, max: 100,
+		get: func(c *Config) float64 { return float64(max(c.Intensity, 0)) },
+		set: func(c *Config, v float64) { c.ApplyIntensity(int(v)) },
+	},
+	{
+		label: "Density",
+		min:   0, max: 3000,
+		get: func(c *Config) float64 { return float64(c.Flakes) },
+		set: func(c *Config, v float64) { c.SetFlakes(int(v)) },
+	},
+	{
+		label: "Speed",
+		min:   1, max: 40,
+		get: func(c *Config) float64 { return c.SpeedMax },
+		set: func(c *Config, v float64) {
+			// Keep the spread between the slowest and fastest flakes
+			ratio := c.SpeedMin / c.SpeedMax
+			c.SpeedMax = v
+			c.SpeedMin = v * ratio
+		},
+	},
+	{
+		label: "Wind",
+		min:   0, max: 10,
+		get: func(c *Config) float64 { return c.Wind },
+		set: func(c *Config, v float64) { c.Wind = v },
+	},
+	{
+		label: "Opacity",
+		min:   0.05, max: 1,
+		get: func(c *Config) float64 { return c.Opacity },
+		set: func(c *Config, v float64) { c.Opacity = v },
+	},
+}
+
+// SettingsOverlay is a small panel of sliders drawn over the snow.
+// Press S to open or close it; changes apply live and are saved on close.
+type SettingsOverlay struct {
+	open     bool
+	dragging int  // Index of the slider being dragged, or -1
+	dirty    bool // Whether there are unsaved changes
+}
+
+// Update handles keyboard and mouse input for the overlay
+func (s *SettingsOverlay) Update(g *Game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		s.toggle(g)
+	} else if s.open && inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		s.toggle(g)
+	}
+	if !s.open {
+		return
+	}
+
+	mx, my := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		s.dragging = -1
+		for i := range settingsSliders {
+			x, y, w := sliderRect(i)
+			if mx >= x-8 && mx <= x+w+8 && my >= y-8 && my <= y+sliderHeight+8 {
+				s.dragging = i
+			}
+		}
+	}
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		s.dragging = -1
+		return
+	}
+	if s.dragging < 0 {
+		return
+	}
+
+	// Map the cursor position onto the slider's range
+	slider := settingsSliders[s.dragging]
+	x, _, w := sliderRect(s.dragging)
+	t := min(max(float64(mx-x)/float64(w), 0), 1)
+
+	cfg := g.config
+	slider.set(&cfg, slider.min+t*(slider.max-slider.min))
+	if err := cfg.Validate(); err == nil {
+		g.ApplyConfig(cfg)
+		s.dirty = true
+	}
+}
+
+// toggle opens or closes the overlay, saving any changes when it closes
+func (s *SettingsOverlay) toggle(g *Game) {
+	s.open = !s.open
+	s.dragging = -1
+
+	if !s.open && s.dirty {
+		s.dirty = false
+		if err := SaveSettings(g.configPath, g.config); err != nil {
+			log.Println("Could not save settings:", err)
+		}
+	}
+}
+
+// Draw renders the overlay if it is open
+func (s *SettingsOverlay) Draw(screen *ebiten.Image, cfg Config) {
+	if !s.open {
+		return
+	}
+
+	height := settingsPadding*2 + 16 + len(settingsSliders)*sliderRowHeight
+	vector.DrawFilledRect(screen, settingsX, settingsY, settingsWidth, float32(height), overlay.panel, false)
+	ebitenutil.DebugPrintAt(screen, "Settings (S or Esc to close)", settingsX+settingsPadding, settingsY+settingsPadding)
+
+	for i, slider := range settingsSliders {
+		x, y, w := sliderRect(i)
+		v := slider.get(&cfg)
+		t := min(max((v-slider.min)/(slider.max-slider.min), 0), 1)
+
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s: %.2f", slider.label, v), x, y-18)
+		vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), sliderHeight, overlay.track, false)
+		vector.DrawFilledCircle(screen, float32(x)+float32(t)*float32(w), float32(y)+sliderHeight/2, sliderHeight, overlay.handle, true)
+	}
+}
+
+// sliderRect returns the position and width of the track of slider i
+func sliderRect(i int) (x, y, w int) {
+	x = settingsX + settingsPadding
+	y = settingsY + settingsPadding + 16 + i*sliderRowHeight + 24
+	return x, y, settingsWidth - settingsPadding*2
+}
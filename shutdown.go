@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Constants for logoff and shutdown notifications
+const (
+	WM_QUERYENDSESSION = 0x0011
+	WM_ENDSESSION      = 0x0016
+)
+
+// How long logoff waits for the game to finish shutting down
+const shutdownTimeout = 3 * time.Second
+
+// WatchEndSession shuts the game down cleanly when the user logs off or
+// Windows shuts down; stopped must be closed once that is done. Call it
+// from the message window's setup function.
+func WatchEndSession(w *MessageWindow, game *Game, stopped <-chan struct{}) {
+	w.Handle(WM_QUERYENDSESSION, func(wParam, lParam uintptr) uintptr {
+		return 1 // Never hold up logoff
+	})
+	w.Handle(WM_ENDSESSION, func(wParam, lParam uintptr) uintptr {
+		if wParam != 0 {
+			// Windows ends the process as soon as this returns, so wait
+			// for the game to stop first
+			game.Post(func(g *Game) { g.quit = true })
+			select {
+			case <-stopped:
+			case <-time.After(shutdownTimeout):
+			}
+		}
+		return 0
+	})
+}
+
+// WatchSignals shuts the game down cleanly on Ctrl+C, or when its console
+// window is closed or the console session ends
+func WatchSignals(game *Game) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	game.Post(func(g *Game) { g.quit = true })
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Ways flake sizes can be spread between size_min and size_max
+const (
+	sizeUniform = "uniform" // Every size equally likely
+	sizeNormal  = "normal"  // Mostly middling, spread by size_spread
+	sizeHeavy   = "heavy"   // Mostly tiny with the odd big one, thinned out by size_tail
+)
+
+// validateSizeDistribution checks the size_distribution setting
+func validateSizeDistribution(dist string) error {
+	switch dist {
+	case sizeUniform, sizeNormal, sizeHeavy:
+		return nil
+	}
+	return fmt.Errorf("size_distribution must be %s, %s or %s, got %q", sizeUniform, sizeNormal, sizeHeavy, dist)
+}
+
+// flakeSize picks a size for a new flake from the configured distribution,
+// always between size_min and size_max
+func (c *Config) flakeSize(r *rand.Rand) float64 {
+	lo, hi := c.SizeMin, c.SizeMax
+	if hi <= lo {
+		return lo
+	}
+
+	switch c.SizeDist {
+	case sizeNormal:
+		// Draw again rather than clamping, so the ends don't bunch up
+		size := (lo + hi) / 2
+		for range 10 {
+			if s := size + r.NormFloat64()*c.SizeSpread; s >= lo && s <= hi {
+				return s
+			}
+		}
+		return size
+	case sizeHeavy:
+		// Pareto distribution cut off at the largest size
+		a := c.SizeTail
+		u := r.Float64()
+		la, ha := math.Pow(lo, a), math.Pow(hi, a)
+		return math.Pow((ha-u*ha+u*la)/(ha*la), -1/a)
+	default:
+		return lo + r.Float64()*(hi-lo)
+	}
+}
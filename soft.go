@@ -0,0 +1,99 @@
+package main
+
+import (
+	_ "embed"
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How soft flakes are drawn
+const (
+	softCore = 0.55 // Share of its quad the solid part of a flake covers, leaving the rest for the glow
+	softHaze = 0.35 // How far flakes at the back fade into the haze
+)
+
+// Color far flakes fade toward
+var softHazeColor = [3]float32{0.62, 0.70, 0.82}
+
+// Kage shader drawing each flake as a soft glowing disc
+//
+//go:embed assets/snow.kage
+var snowKage []byte
+
+// The compiled snow shader, made on first use, and whether it wouldn't
+// compile on this GPU, in which case soft flakes are drawn as dots
+var (
+	snowShader *ebiten.Shader
+	snowFailed bool
+)
+
+// SoftFlakes gathers the flakes drawn by the snow shader, so the whole
+// snow field goes to the GPU in one DrawTrianglesShader call
+type SoftFlakes struct {
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+// drawSoft queues a flake to be drawn onto target as a soft glowing disc
+// in c. A full queue is drawn first so its indices still fit.
+func (g *Game) drawSoft(target *ebiten.Image, f Snowflake, c color.NRGBA) {
+	s := &g.soft
+	if len(s.vertices)/4 >= batchQuads {
+		g.flushSoft(target)
+	}
+
+	// The quad is wider than the flake to leave room for its glow
+	radius := float32(max(f.size, 1) / 2 / softCore)
+	depth := float32(0.5)
+	if cfg := g.config; cfg.SizeMax > cfg.SizeMin {
+		depth = float32(min(max((f.size-cfg.SizeMin)/(cfg.SizeMax-cfg.SizeMin), 0), 1))
+	}
+	base := uint16(len(s.vertices))
+	for _, corner := range [4][2]float32{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		s.vertices = append(s.vertices, ebiten.Vertex{
+			DstX:    float32(f.x) + corner[0]*radius,
+			DstY:    float32(f.y) + corner[1]*radius,
+			SrcX:    corner[0],
+			SrcY:    corner[1],
+			ColorR:  float32(c.R) / 255,
+			ColorG:  float32(c.G) / 255,
+			ColorB:  float32(c.B) / 255,
+			ColorA:  float32(c.A) / 255,
+			Custom0: depth,
+			Custom1: softCore,
+		})
+	}
+	s.indices = append(s.indices, base, base+1, base+2, base+1, base+3, base+2)
+}
+
+// flushSoft draws the queued soft flakes onto target
+func (g *Game) flushSoft(target *ebiten.Image) {
+	s := &g.soft
+	if len(s.vertices) == 0 {
+		return
+	}
+	defer func() {
+		s.vertices = s.vertices[:0]
+		s.indices = s.indices[:0]
+	}()
+
+	if snowShader == nil {
+		shader, err := ebiten.NewShader(snowKage)
+		if err != nil {
+			log.Println("Could not compile the snow shader:", err)
+			snowFailed = true
+			return
+		}
+		snowShader = shader
+	}
+
+	op := &ebiten.DrawTrianglesShaderOptions{}
+	op.Uniforms = map[string]any{
+		"Glow":       float32(g.config.Glow),
+		"Haze":       softHazeColor[:],
+		"HazeAmount": float32(softHaze),
+	}
+	target.DrawTrianglesShader(s.vertices, s.indices, snowShader, op)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Ways of drawing the snowflakes
+const (
+	flakeStyleCrystal = "crystal" // Rotating flakes with shapes generated at startup
+	flakeStyleSprite  = "sprite"  // Rotating textured flakes from the embedded atlas
+	flakeStyleDot     = "dot"     // Plain round dots
+	flakeStyleCustom  = "custom"  // The user's own images from sprite_folder
+	flakeStyleGlyph   = "glyph"   // Characters and emoji from the glyphs setting
+	flakeStyleShader  = "shader"  // Soft glowing discs drawn by a shader, hazier toward the back
+)
+
+// Layout and motion of the flake sprites
+const (
+	spriteCell  = 32   // Width and height of each design in the atlas
+	spriteScale = 3    // Sprites are drawn this many times the flake size, since the arms are thin
+	spriteSpin  = 0.08 // Radians per frame a one pixel flake turns in still air
+	spriteWind  = 0.02 // Extra radians per frame for each unit of wind
+	dotCell     = 16   // Width and height of the disc dots are scaled from
+)
+
+// Several flake designs side by side, white on transparent
+//
+//go:embed assets/flakes.png
+var flakeAtlasPNG []byte
+
+// The atlas and the designs cut out of it, loaded on first use
+var (
+	flakeAtlas   *ebiten.Image
+	flakeSprites []*ebiten.Image
+)
+
+// The disc plain dots are drawn with, made on first use
+var dotSprite *ebiten.Image
+
+// loadFlakeSprites decodes the embedded atlas and splits it into one image per design
+func loadFlakeSprites() []*ebiten.Image {
+	if flakeSprites != nil {
+		return flakeSprites
+	}
+
+	img, err := png.Decode(bytes.NewReader(flakeAtlasPNG))
+	if err != nil {
+		log.Fatal("Could not decode the flake atlas: ", err)
+	}
+	flakeAtlas = ebiten.NewImageFromImage(img)
+	for x := 0; x+spriteCell <= flakeAtlas.Bounds().Dx(); x += spriteCell {
+		flakeSprites = append(flakeSprites, flakeAtlas.SubImage(image.Rect(x, 0, x+spriteCell, spriteCell)).(*ebiten.Image))
+	}
+	return flakeSprites
+}
+
+// validateFlakeStyle checks the flake_style setting
+func validateFlakeStyle(style string) error {
+	switch style {
+	case flakeStyleCrystal, flakeStyleSprite, flakeStyleDot, flakeStyleCustom, flakeStyleGlyph, flakeStyleShader:
+		return nil
+	}
+	return fmt.Errorf("flake_style must be %s, %s, %s, %s, %s or %s, got %q", flakeStyleCrystal, flakeStyleSprite, flakeStyleDot, flakeStyleCustom, flakeStyleGlyph, flakeStyleShader, style)
+}
+
+// spinFlake gives a new flake a random design and starting angle, and a
+// spin that is faster for small flakes, in either direction. The design
+// indexes the generated crystals, and wraps around the fewer built-in sprites.
+func spinFlake(f *Snowflake, r *rand.Rand) {
+	f.design = r.Intn(crystalDesigns)
+	f.angle = r.Float64() * 2 * math.Pi
+	f.spin = spriteSpin / f.size * (0.5 + r.Float64())
+	if r.Intn(2) == 0 {
+		f.spin = -f.spin
+	}
+}
+
+// turn advances the flake's rotation, spinning it faster in a strong wind
+func (f *Snowflake) turn(wind float64) {
+	spin := f.spin + math.Copysign(spriteWind*math.Abs(wind)/f.size, f.spin)
+	f.angle = math.Mod(f.angle+spin, 2*math.Pi)
+}
+
+// drawSprite draws a flake as its rotated design, tinted with c, in the
+// batch for its design
+func (g *Game) drawSprite(screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	sprites := g.crystals
+	if g.config.FlakeStyle == flakeStyleSprite {
+		sprites = loadFlakeSprites()
+	}
+	scale := f.size * spriteScale / spriteCell
+
+	var m ebiten.GeoM
+	m.Translate(-spriteCell/2, -spriteCell/2)
+	m.Rotate(f.angle)
+	m.Scale(scale, scale)
+	m.Translate(f.x, f.y)
+	g.batch.Add(screen, sprites[f.design%len(sprites)], m, c)
+}
+
+// drawDot draws a flake as a round dot in c. Every dot is the same small
+// disc scaled to the flake's size, so they are all drawn in one batch.
+func (g *Game) drawDot(screen *ebiten.Image, f Snowflake, c color.NRGBA) {
+	scale := max(f.size, 1) / dotCell
+
+	var m ebiten.GeoM
+	m.Translate(-dotCell/2, -dotCell/2)
+	m.Scale(scale, scale)
+	m.Translate(f.x, f.y)
+	g.batch.Add(screen, loadDotSprite(), m, c)
+}
+
+// loadDotSprite draws the white disc dots are scaled from, on first use
+func loadDotSprite() *ebiten.Image {
+	if dotSprite != nil {
+		return dotSprite
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, dotCell, dotCell))
+	half := dotCell / 2.0
+	for py := range dotCell {
+		for px := range dotCell {
+			d := math.Hypot(float64(px)+0.5-half, float64(py)+0.5-half)
+			// A pixel's worth of soft edge
+			a := min(max(half-d, 0), 1)
+			img.SetNRGBA(px, py, color.NRGBA{0xff, 0xff, 0xff, uint8(a * 255)})
+		}
+	}
+	dotSprite = ebiten.NewImageFromImage(img)
+	return dotSprite
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Ways the night sky can be shown
+const (
+	starsOff    = "off"    // No stars
+	starsBehind = "behind" // Stars behind the falling snow
+	starsAlone  = "alone"  // Stars on a clear night with nothing falling
+)
+
+// How the stars look and how shooting stars streak across
+const (
+	starDensity    = 120  // Stars per million pixels of screen
+	starSizeMax    = 1.6  // Radius of the brightest stars in pixels
+	starTwinkleMax = 0.05 // Fastest a star twinkles in radians per frame
+	shootingSpeed  = 14.0 // Pixels per frame a shooting star crosses the sky
+	shootingFrames = 45   // Frames a shooting star lasts
+	shootingTail   = 10   // Pieces in the tail, each half a frame of movement long
+)
+
+// star is a fixed point of light in the night sky
+type star struct {
+	x, y    float64
+	size    float64 // Radius in pixels
+	shimmer float64 // Where the star is in its twinkle, in radians
+	flicker float64 // How fast it twinkles in radians per frame
+}
+
+// shootingStar is a meteor streaking down across the sky
+type shootingStar struct {
+	x, y   float64
+	vx, vy float64
+	frames int // Frames left before it burns out
+}
+
+// Starfield is the night sky drawn behind the snow
+type Starfield struct {
+	stars    []star
+	shooting []shootingStar
+	width    int // Screen size the stars were scattered over
+	height   int
+}
+
+// validateStars checks the stars setting
+func validateStars(mode string) error {
+	switch mode {
+	case starsOff, starsBehind, starsAlone:
+		return nil
+	}
+	return fmt.Errorf("stars must be %s, %s or %s, got %q", starsOff, starsBehind, starsAlone, mode)
+}
+
+// updateStars twinkles the stars and now and then sends a shooting star
+// across the sky, scattering a fresh sky if the screen has changed size
+func (g *Game) updateStars(r *rand.Rand) {
+	s := &g.sky
+	if g.config.Stars == starsOff {
+		s.stars, s.shooting = nil, nil
+		return
+	}
+
+	if s.stars == nil || s.width != g.screenWidth || s.height != g.screenHeight {
+		s.scatter(g.screenWidth, g.screenHeight, r)
+	}
+	for i := range s.stars {
+		s.stars[i].shimmer = math.Mod(s.stars[i].shimmer+s.stars[i].flicker, 2*math.Pi)
+	}
+
+	if r.Float64() < g.config.ShootingStars/60/float64(g.stepRate()) {
+		s.shoot(r)
+	}
+	for i := range s.shooting {
+		m := &s.shooting[i]
+		m.x += m.vx
+		m.y += m.vy
+		m.frames--
+	}
+	s.shooting = slices.DeleteFunc(s.shooting, func(m shootingStar) bool { return m.frames <= 0 })
+}
+
+// scatter spreads stars at random over a screen of the given size
+func (s *Starfield) scatter(width, height int, r *rand.Rand) {
+	s.width, s.height = width, height
+	s.stars = make([]star, int(float64(width*height)*starDensity/1e6))
+	for i := range s.stars {
+		s.stars[i] = star{
+			x: r.Float64() * float64(width),
+			y: r.Float64() * float64(height),
+			// Mostly faint stars with a few bright ones
+			size:    0.4 + math.Pow(r.Float64(), 3)*(starSizeMax-0.4),
+			shimmer: r.Float64() * 2 * math.Pi,
+			flicker: r.Float64() * starTwinkleMax,
+		}
+	}
+}
+
+// shoot starts a shooting star somewhere in the upper part of the sky,
+// heading down and to one side
+func (s *Starfield) shoot(r *rand.Rand) {
+	angle := math.Pi/8 + r.Float64()*math.Pi/4 // Below the horizontal
+	dir := 1.0
+	if r.Intn(2) == 0 {
+		dir = -1
+	}
+	s.shooting = append(s.shooting, shootingStar{
+		x:      r.Float64() * float64(s.width),
+		y:      r.Float64() * float64(s.height) / 3,
+		vx:     dir * math.Cos(angle) * shootingSpeed,
+		vy:     math.Sin(angle) * shootingSpeed,
+		frames: shootingFrames,
+	})
+}
+
+// drawStars draws the night sky, if it is showing
+func (g *Game) drawStars(screen *ebiten.Image) {
+	for _, s := range g.sky.stars {
+		bright := 0.55 + 0.45*math.Sin(s.shimmer)
+		c := color.NRGBA{255, 255, 255, uint8(bright * 255)}
+		vector.DrawFilledCircle(screen, float32(s.x), float32(s.y), float32(s.size), c, true)
+	}
+
+	// Shooting stars fade in and out, with a tail that fades toward its end
+	for _, m := range g.sky.shooting {
+		life := float64(m.frames) / shootingFrames
+		bright := math.Sin(life * math.Pi)
+		for i := range shootingTail {
+			t0, t1 := float64(i), float64(i+1)
+			c := color.NRGBA{255, 255, 255, uint8(bright * (1 - t0/shootingTail) * 255)}
+			vector.StrokeLine(screen,
+				float32(m.x-m.vx*t0/2), float32(m.y-m.vy*t0/2),
+				float32(m.x-m.vx*t1/2), float32(m.y-m.vy*t1/2),
+				float32(1.5*(1-t0/shootingTail)+0.5), c, true)
+		}
+	}
+}
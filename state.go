@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Name of the file the snow lying on the ground is kept in between runs,
+// beside the config file
+const stateFileName = "state.json"
+
+// savedPile is the snow lying on one surface when winsnow last exited
+type savedPile struct {
+	Kind  surfaceKind `json:"kind"`
+	X     float64     `json:"x"`
+	Y     float64     `json:"y"`
+	Width float64     `json:"width"`
+	Depth []float64   `json:"depth"`
+}
+
+// savedPiles are the saved piles by surface, waiting for their surfaces to
+// turn up again
+type savedPiles map[uintptr]savedPile
+
+// statePath returns where the snow is kept between runs, or "" if the
+// config directory is unknown
+func (g *Game) statePath() string {
+	if g.configPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(g.configPath), stateFileName)
+}
+
+// keepsPile reports whether a kind of pile is kept between runs. Windows
+// and their handles don't outlive the session, and the pile on the
+// pointer is gone as soon as it moves, so only snow on the ground, the
+// taskbar and monitor tops is kept. No other state is saved.
+func keepsPile(kind surfaceKind) bool {
+	return kind != surfaceWindow && kind != surfaceCursor
+}
+
+// saveState writes the piles out so the next run can pick them up again
+func (g *Game) saveState() {
+	path := g.statePath()
+	if path == "" {
+		return
+	}
+	piles := savedPiles{}
+	for id, p := range g.piles {
+		if keepsPile(p.kind) {
+			piles[id] = savedPile{Kind: p.kind, X: p.x, Y: p.y, Width: p.width(), Depth: p.depth}
+		}
+	}
+	data, err := json.Marshal(piles)
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		log.Println("Could not save the snow on the ground:", err)
+	}
+}
+
+// loadState reads back the piles saved by the last run. Each is restored
+// once a pile lies on the same surface in the same place again.
+func (g *Game) loadState() {
+	path := g.statePath()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Println("Could not read the snow saved last time:", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &g.saved); err != nil {
+		log.Println("Could not read the snow saved last time:", err)
+		return
+	}
+	for id, p := range g.piles {
+		g.restorePile(id, p)
+	}
+}
+
+// restorePile fills a new pile with the snow saved on its surface, if it
+// still lies where it did
+func (g *Game) restorePile(id uintptr, p *Pile) {
+	s, ok := g.saved[id]
+	if !ok || s.Kind != p.kind || !p.On(s.X, s.Y, s.Width) || len(s.Depth) != len(p.depth) {
+		return
+	}
+	copy(p.depth, s.Depth)
+	delete(g.saved, id)
+}
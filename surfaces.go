@@ -0,0 +1,195 @@
+package main
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for finding the taskbar and application windows
+const (
+	ABM_GETTASKBARPOS           = 0x00000005
+	ABE_BOTTOM                  = 3
+	DWMWA_EXTENDED_FRAME_BOUNDS = 9
+	DWMWA_CLOAKED               = 14
+	minSurfaceWidth             = 40 // Narrower windows don't catch snow
+)
+
+// appBarData mirrors the Windows APPBARDATA structure
+type appBarData struct {
+	cbSize           uint32
+	hWnd             uintptr
+	uCallbackMessage uint32
+	uEdge            uint32
+	rc               rect
+	lParam           uintptr
+}
+
+// Kinds of surface snow can pile up on
+type surfaceKind int
+
+const (
+	surfaceTaskbar surfaceKind = iota
+	surfaceWindow
+	surfaceGround
+	surfaceCursor
+	surfaceMonitor
+)
+
+// Key of the ground pile, which has no window of its own
+const groundPile uintptr = 0
+
+// surface is an edge snow can land on, in physical screen pixels
+type surface struct {
+	kind   surfaceKind
+	left   int32 // Left end of the edge
+	right  int32 // Right end of the edge
+	top    int32 // Height of the edge
+	bottom int32 // Height of the bottom of the window, for windows
+}
+
+// WatchSurfaces tells the game where the edges snow can pile up on are,
+// checking twice a second
+func WatchSurfaces(game *Game) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	for range ticker.C {
+		own := FindGameWindow()
+		if own == 0 {
+			continue
+		}
+		var window rect
+		procGetWindowRect.Call(own, uintptr(unsafe.Pointer(&window)))
+
+		surfaces := windowSurfaces(own)
+		if hwnd, s, ok := taskbarSurface(); ok {
+			surfaces[hwnd] = s
+		}
+		for i, d := range Displays(false) {
+			surfaces[monitorSurface(i)] = surface{kind: surfaceMonitor, left: d.left, right: d.right, top: d.top}
+		}
+		game.Post(func(g *Game) { g.SetSurfaces(window, surfaces) })
+	}
+}
+
+// taskbarSurface returns the top edge of the taskbar, if it is docked at the bottom
+func taskbarSurface() (uintptr, surface, bool) {
+	abd := appBarData{}
+	abd.cbSize = uint32(unsafe.Sizeof(abd))
+	if ok, _, _ := procSHAppBarMessage.Call(ABM_GETTASKBARPOS, uintptr(unsafe.Pointer(&abd))); ok == 0 || abd.uEdge != ABE_BOTTOM {
+		return 0, surface{}, false
+	}
+	return abd.hWnd, surface{kind: surfaceTaskbar, left: abd.rc.left, right: abd.rc.right, top: abd.rc.top}, true
+}
+
+// The window edges found by the last enumeration
+var foundSurfaces map[uintptr]surface
+
+// collectWindowSurface is the EnumWindows callback. It keeps the top edge
+// of each application window that is showing on screen.
+var collectWindowSurface = windows.NewCallback(func(hwnd, lParam uintptr) uintptr {
+	if visible, _, _ := procIsWindowVisible.Call(hwnd); visible == 0 {
+		return 1
+	}
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		return 1
+	}
+	if hwnd == lParam || shellClasses[windowClass(hwnd)] { // lParam is the snow window
+		return 1
+	}
+
+	// Tool windows are palettes and popups rather than application windows
+	index := GWL_EXSTYLE
+	if style, _, _ := procGetWindowLongPtr.Call(hwnd, uintptr(index)); style&WS_EX_TOOLWINDOW != 0 {
+		return 1
+	}
+
+	// Windows on other virtual desktops and suspended store apps are
+	// visible but cloaked
+	var cloaked uint32
+	procDwmGetWindowAttribute.Call(hwnd, DWMWA_CLOAKED, uintptr(unsafe.Pointer(&cloaked)), unsafe.Sizeof(cloaked))
+	if cloaked != 0 {
+		return 1
+	}
+
+	// The frame bounds leave out the invisible resize borders around the window
+	var r rect
+	if hr, _, _ := procDwmGetWindowAttribute.Call(hwnd, DWMWA_EXTENDED_FRAME_BOUNDS, uintptr(unsafe.Pointer(&r)), unsafe.Sizeof(r)); hr != 0 {
+		procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+	}
+	if r.right-r.left >= minSurfaceWidth {
+		foundSurfaces[hwnd] = surface{kind: surfaceWindow, left: r.left, right: r.right, top: r.top, bottom: r.bottom}
+	}
+	return 1
+})
+
+// windowSurfaces returns the top edges of the application windows on
+// screen, other than the snow window own
+func windowSurfaces(own uintptr) map[uintptr]surface {
+	foundSurfaces = map[uintptr]surface{}
+	procEnumWindows.Call(collectWindowSurface, own)
+	return foundSurfaces
+}
+
+// SetSurfaces updates the piles and icicles to match the edges found on
+// screen. window is the snow window's screen rectangle, used to convert to
+// its coordinates. Piles on edges that have gone or moved are dropped.
+func (g *Game) SetSurfaces(window rect, surfaces map[uintptr]surface) {
+	if window.right <= window.left {
+		return
+	}
+	scale := float64(g.screenWidth) / float64(window.right-window.left)
+
+	for id := range g.piles {
+		if _, ok := surfaces[id]; !ok && id != groundPile {
+			delete(g.piles, id)
+		}
+	}
+	for id := range g.eaves {
+		if _, ok := surfaces[id]; !ok {
+			delete(g.eaves, id)
+		}
+	}
+	stood := map[uintptr]rect{}
+	for id, s := range surfaces {
+		x := float64(s.left-window.left) * scale
+		y := float64(s.top-window.top) * scale
+		width := float64(s.right-s.left) * scale
+		g.hang(id, s.kind, x, y, width)
+		if s.kind == surfaceWindow {
+			// Only windows that have just been put down leave footprints,
+			// so ones sitting still don't keep stamping out fresh snow
+			r := rect{s.left, s.top, s.right, s.bottom}
+			if old, ok := g.stood[id]; !ok || old != r {
+				g.tread(x, float64(s.bottom-window.top)*scale, width)
+			}
+			stood[id] = r
+		}
+
+		if !g.pilesOn(s.kind) {
+			delete(g.piles, id)
+			continue
+		}
+		if p, ok := g.piles[id]; ok {
+			if p.On(x, y, width) {
+				continue
+			}
+			// The window was moved or resized, so knock its snow off
+			g.knockOff(p)
+		}
+		g.piles[id] = NewPile(s.kind, x, y, width)
+		g.restorePile(id, g.piles[id])
+	}
+	g.stood = stood
+}
+
+// pilesOn reports whether snow should pile up on a kind of surface
+func (g *Game) pilesOn(kind surfaceKind) bool {
+	switch kind {
+	case surfaceTaskbar:
+		return g.config.PileOnTaskbar
+	case surfaceWindow:
+		return g.config.PileOnWindows
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// surprise picks random weather and schedules the next change
+func (g *Game) surprise(r *rand.Rand) {
+	cfg := g.config
+
+	// Start from a random preset so the result stays within sensible bounds,
+	// then vary each setting around it
+	names := cfg.PresetNames()
+	cfg.ApplyPreset(names[r.Intn(len(names))])
+	cfg.Preset = ""
+
+	cfg.SetFlakes(int(float64(cfg.Flakes) * (0.5 + r.Float64())))
+	cfg.SpeedMin *= 0.7 + r.Float64()*0.6
+	cfg.SpeedMax = cfg.SpeedMin * (1.5 + r.Float64()*2)
+	cfg.SizeMin = 0.5 + r.Float64()*1.5
+	cfg.SizeMax = cfg.SizeMin + r.Float64()*5
+	cfg.Wind *= 0.5 + r.Float64()*1.5
+
+	if err := cfg.Validate(); err == nil {
+		g.ApplyConfig(cfg)
+	}
+
+	g.nextSurprise = time.Now().Add(time.Duration(cfg.SurpriseMinutes * float64(time.Minute)))
+}
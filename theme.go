@@ -0,0 +1,107 @@
+package main
+
+import (
+	"image/color"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Where Windows records whether apps use the light or dark theme
+const (
+	personalizeKey    = `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`
+	lightThemeValue   = "AppsUseLightTheme"
+	themeChangedParam = "ImmersiveColorSet" // WM_SETTINGCHANGE parameter sent on theme changes
+)
+
+// overlayPalette holds the colors of the settings panel and setup wizard
+type overlayPalette struct {
+	panel, track, handle color.NRGBA
+	button, buttonChosen color.NRGBA
+	flake, backdropTint  string // Used for color and backdrop_tint when following the theme
+}
+
+// The dark palette also serves when follow_theme is off. Overlay text is
+// always white, so the light palette stays dark enough to read it.
+var (
+	darkPalette = overlayPalette{
+		panel:        color.NRGBA{20, 30, 45, 220},
+		track:        color.NRGBA{90, 100, 120, 255},
+		handle:       color.NRGBA{200, 220, 255, 255},
+		button:       color.NRGBA{60, 75, 100, 255},
+		buttonChosen: color.NRGBA{110, 150, 210, 255},
+		flake:        "#ffffff",
+		backdropTint: "#c8dcf0",
+	}
+	lightPalette = overlayPalette{
+		panel:        color.NRGBA{60, 80, 110, 230},
+		track:        color.NRGBA{150, 165, 190, 255},
+		handle:       color.NRGBA{245, 250, 255, 255},
+		button:       color.NRGBA{90, 110, 140, 255},
+		buttonChosen: color.NRGBA{60, 120, 200, 255},
+		flake:        "#8fa8c8",
+		backdropTint: "#3c5070",
+	}
+)
+
+// The palette the overlays are drawn with
+var overlay = darkPalette
+
+// LightTheme reports whether Windows apps are set to the light theme
+func LightTheme() bool {
+	k, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer k.Close()
+
+	light, _, err := k.GetIntegerValue(lightThemeValue)
+	return err == nil && light != 0
+}
+
+// Themed returns the settings with the flake and backdrop colors swapped
+// for ones that suit the Windows theme, if follow_theme is on. Colors the
+// user has changed from the defaults are kept.
+func (c Config) Themed(light bool) Config {
+	if !c.FollowTheme {
+		return c
+	}
+	p := darkPalette
+	if light {
+		p = lightPalette
+	}
+	defaults := DefaultConfig()
+	if c.Color == defaults.Color {
+		c.Color = p.flake
+	}
+	if c.BackdropTint == defaults.BackdropTint {
+		c.BackdropTint = p.backdropTint
+	}
+	return c
+}
+
+// WatchTheme tells the game when the user switches between the light and
+// dark theme. Call it from the message window's setup function.
+func WatchTheme(w *MessageWindow, game *Game) {
+	w.Handle(WM_SETTINGCHANGE, func(wParam, lParam uintptr) uintptr {
+		if lParam != 0 && windows.UTF16PtrToString(*(**uint16)(unsafe.Pointer(&lParam))) == themeChangedParam {
+			light := LightTheme()
+			game.Post(func(g *Game) { g.SetLightTheme(light) })
+		}
+		return 0
+	})
+
+	light := LightTheme()
+	game.Post(func(g *Game) { g.SetLightTheme(light) })
+}
+
+// SetLightTheme switches the colors to suit the light or dark theme
+func (g *Game) SetLightTheme(light bool) {
+	if light == g.lightTheme {
+		return
+	}
+	g.lightTheme = light
+	g.updateColor()
+	ApplyBackdrop(FindGameWindow(), g.displayConfig(g.config))
+}
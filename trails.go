@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How faint a trail is at its end, as a share of the flake's brightness
+const trailEnd = 0.05
+
+// A single white pixel, stretched over the screen to fade it
+var trailPixel *ebiten.Image
+
+// clearScreen clears the screen to black, or to transparent so the blurred
+// backdrop shows through. With trails on, the last frame is faded instead
+// of wiped, leaving streaks behind moving flakes.
+func (g *Game) clearScreen(screen *ebiten.Image) {
+	solid := g.config.Backdrop == backdropNone || g.solid
+
+	// Panels are drawn translucent over the snow, so they would build up
+	if g.config.TrailLength <= 0 || g.overlayOpen() {
+		if solid {
+			screen.Fill(color.RGBA{0, 0, 0, 255})
+		} else {
+			screen.Fill(color.RGBA{})
+		}
+		return
+	}
+
+	if trailPixel == nil {
+		trailPixel = ebiten.NewImage(1, 1)
+		trailPixel.Fill(color.White)
+	}
+	bounds := screen.Bounds()
+
+	// Knock back everything drawn so far so it is down to trailEnd
+	// after trail_length frames
+	fade := 1 - math.Pow(trailEnd, 1/float64(g.config.TrailLength))
+	op := &ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationOut}
+	op.GeoM.Scale(float64(bounds.Dx()), float64(bounds.Dy()))
+	op.ColorScale.ScaleAlpha(float32(fade))
+	screen.DrawImage(trailPixel, op)
+
+	// Then fill what was faded out with black again
+	if solid {
+		op := &ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationOver}
+		op.GeoM.Scale(float64(bounds.Dx()), float64(bounds.Dy()))
+		op.ColorScale.Scale(0, 0, 0, 1)
+		screen.DrawImage(trailPixel, op)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"log"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for the notification area icon and its menu
+const (
+	WM_APP          = 0x8000
+	WM_TRAYICON     = WM_APP + 1
+	WM_TRAYMENU     = WM_APP + 2 // The menu's state has been gathered
+	WM_NULL         = 0x0000
+	WM_LBUTTONUP    = 0x0202
+	WM_RBUTTONUP    = 0x0205
+	NIM_ADD         = 0x00000000
+	NIM_DELETE      = 0x00000002
+	NIF_MESSAGE     = 0x00000001
+	NIF_ICON        = 0x00000002
+	NIF_TIP         = 0x00000004
+	MF_STRING       = 0x00000000
+	MF_CHECKED      = 0x00000008
+	MF_POPUP        = 0x00000010
+	MF_SEPARATOR    = 0x00000800
+	TPM_RIGHTBUTTON = 0x0002
+	TPM_NONOTIFY    = 0x0080
+	TPM_RETURNCMD   = 0x0100
+	IDI_APPLICATION = 32512
+)
+
+// Tray menu command IDs; presets, effects and palettes are numbered from
+// cmdPreset, cmdEffect and cmdPalette upwards
+const (
+	cmdPause = iota + 1
+	cmdSettings
+	cmdAutostart
+	cmdService
+	cmdExit
+	cmdPreset  = 100
+	cmdEffect  = 200
+	cmdPalette = 300
+)
+
+// notifyIconData mirrors the Windows NOTIFYICONDATAW structure
+type notifyIconData struct {
+	cbSize           uint32
+	hWnd             uintptr
+	uID              uint32
+	uFlags           uint32
+	uCallbackMessage uint32
+	hIcon            uintptr
+	szTip            [128]uint16
+	dwState          uint32
+	dwStateMask      uint32
+	szInfo           [256]uint16
+	uVersion         uint32
+	szInfoTitle      [64]uint16
+	dwInfoFlags      uint32
+	guidItem         windows.GUID
+	hBalloonIcon     uintptr
+}
+
+// Tray is the notification area icon with the control menu
+type Tray struct {
+	game  *Game
+	nid   notifyIconData
+	menus chan trayState // State gathered for the menu, waiting to be shown
+}
+
+// trayState is a snapshot of the game used to build the menu
+type trayState struct {
+	paused    bool
+	autostart bool
+	preset    string
+	presets   []string
+	effect    string
+	palette   string
+	service   bool // Whether the supervisor is running
+}
+
+// AddTray adds the icon to the notification area. Call it from the
+// message window's setup function.
+func AddTray(w *MessageWindow, game *Game) *Tray {
+	t := &Tray{game: game, menus: make(chan trayState, 1)}
+	t.nid.cbSize = uint32(unsafe.Sizeof(t.nid))
+	t.nid.hWnd = w.hwnd
+	t.nid.uID = 1
+	t.nid.uFlags = NIF_MESSAGE | NIF_ICON | NIF_TIP
+	t.nid.uCallbackMessage = WM_TRAYICON
+	t.nid.hIcon, _, _ = procLoadIcon.Call(0, IDI_APPLICATION)
+	copy(t.nid.szTip[:], windows.StringToUTF16("winsnow"))
+
+	w.Handle(WM_TRAYICON, func(wParam, lParam uintptr) uintptr {
+		if lParam == WM_LBUTTONUP || lParam == WM_RBUTTONUP {
+			go t.gather(w.hwnd)
+		}
+		return 0
+	})
+	w.Handle(WM_TRAYMENU, func(wParam, lParam uintptr) uintptr {
+		select {
+		case state := <-t.menus:
+			t.showMenu(w.hwnd, state)
+		default:
+		}
+		return 0
+	})
+
+	// Explorer forgets the icon when it restarts, so add it again
+	name, _ := windows.UTF16PtrFromString("TaskbarCreated")
+	if taskbarCreated, _, _ := procRegisterWindowMessage.Call(uintptr(unsafe.Pointer(name))); taskbarCreated != 0 {
+		w.Handle(uint32(taskbarCreated), func(wParam, lParam uintptr) uintptr {
+			t.add()
+			return 0
+		})
+	}
+
+	t.add()
+	return t
+}
+
+// add shows the icon
+func (t *Tray) add() {
+	if ok, _, err := procShellNotifyIcon.Call(NIM_ADD, uintptr(unsafe.Pointer(&t.nid))); ok == 0 {
+		log.Println("Could not add tray icon:", err)
+	}
+}
+
+// Remove takes the icon out of the notification area
+func (t *Tray) Remove() {
+	procShellNotifyIcon.Call(NIM_DELETE, uintptr(unsafe.Pointer(&t.nid)))
+}
+
+// gather reads what the menu needs and then asks the message window to show
+// it. It waits on the game and the supervisor, so it runs on its own
+// goroutine rather than holding up the message loop.
+func (t *Tray) gather(hwnd uintptr) {
+	var state trayState
+	t.game.Call(func(g *Game) {
+		state = trayState{
+			paused:    g.paused&pausedByUser != 0,
+			autostart: g.config.Autostart,
+			preset:    g.config.Preset,
+			presets:   g.config.PresetNames(),
+			effect:    g.config.Effect,
+			palette:   g.config.Palette,
+		}
+	})
+
+	// Asking the supervisor is quick: with none running the pipe doesn't exist
+	_, err := SendServiceCommand("status")
+	state.service = err == nil
+
+	// A click while the menu is already waiting is dropped
+	select {
+	case t.menus <- state:
+		procPostMessage.Call(hwnd, WM_TRAYMENU, 0, 0)
+	default:
+	}
+}
+
+// showMenu pops up the control menu at the cursor and carries out the choice
+func (t *Tray) showMenu(hwnd uintptr, state trayState) {
+	presets, _, _ := procCreatePopupMenu.Call()
+	for i, name := range state.presets {
+		appendMenu(presets, MF_STRING|checkedIf(name == state.preset), cmdPreset+i, name)
+	}
+	effectMenu, _, _ := procCreatePopupMenu.Call()
+	for i, name := range effectNames {
+		appendMenu(effectMenu, MF_STRING|checkedIf(name == state.effect), cmdEffect+i, name)
+	}
+	paletteMenu, _, _ := procCreatePopupMenu.Call()
+	for i, name := range paletteNames {
+		appendMenu(paletteMenu, MF_STRING|checkedIf(name == state.palette), cmdPalette+i, name)
+	}
+
+	menu, _, _ := procCreatePopupMenu.Call()
+	defer procDestroyMenu.Call(menu)
+	appendMenu(menu, MF_STRING|checkedIf(state.paused), cmdPause, "Pause")
+	appendMenu(menu, MF_POPUP, int(presets), "Intensity")
+	appendMenu(menu, MF_POPUP, int(effectMenu), "Effect")
+	appendMenu(menu, MF_POPUP, int(paletteMenu), "Colors")
+	appendMenu(menu, MF_STRING, cmdSettings, "Settings...")
+	appendMenu(menu, MF_STRING|checkedIf(state.autostart), cmdAutostart, "Start at login")
+	if state.service {
+		appendMenu(menu, MF_STRING, cmdService, "Stop service")
+	} else {
+		appendMenu(menu, MF_STRING, cmdService, "Start service")
+	}
+	appendMenu(menu, MF_SEPARATOR, 0, "")
+	appendMenu(menu, MF_STRING, cmdExit, "Exit")
+
+	// The menu only closes on an outside click if our window is in the foreground
+	var pt struct{ x, y int32 }
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	procSetForegroundWindow.Call(hwnd)
+	cmd, _, _ := procTrackPopupMenu.Call(menu, TPM_RIGHTBUTTON|TPM_RETURNCMD|TPM_NONOTIFY,
+		uintptr(pt.x), uintptr(pt.y), 0, hwnd, 0)
+	procPostMessage.Call(hwnd, WM_NULL, 0, 0)
+
+	switch {
+	case cmd == cmdPause:
+		t.game.Post(func(g *Game) {
+			if state.paused {
+				g.Resume(pausedByUser)
+			} else {
+				g.Pause(pausedByUser)
+			}
+		})
+	case cmd == cmdSettings:
+		t.game.Post(func(g *Game) {
+			if !g.settings.open {
+				g.settings.toggle(g)
+			}
+		})
+	case cmd == cmdAutostart:
+		t.game.Post(func(g *Game) {
+			cfg := g.config
+			cfg.Autostart = !state.autostart
+			g.ApplyConfig(cfg)
+			if err := SaveSettings(g.configPath, cfg); err != nil {
+				log.Println("Could not save settings:", err)
+			}
+		})
+	case cmd == cmdService && state.service:
+		// The supervisor quits this renderer on its way out, so don't hold
+		// up the message loop waiting for it
+		go func() {
+			if _, err := SendServiceCommand("exit"); err != nil {
+				log.Println("Could not stop the service:", err)
+			}
+		}()
+	case cmd == cmdService:
+		// Hand over to the renderer the supervisor starts
+		if err := LaunchService(); err != nil {
+			log.Println("Could not start the service:", err)
+			break
+		}
+		t.game.Post(func(g *Game) { g.quit = true })
+	case cmd == cmdExit:
+		t.game.Post(func(g *Game) { g.quit = true })
+	case cmd >= cmdPreset && int(cmd-cmdPreset) < len(state.presets):
+		name := state.presets[cmd-cmdPreset]
+		t.game.Post(func(g *Game) { g.SetPreset(name) })
+	case cmd >= cmdEffect && int(cmd-cmdEffect) < len(effectNames):
+		name := effectNames[cmd-cmdEffect]
+		t.game.Post(func(g *Game) { g.SetEffect(name) })
+	case cmd >= cmdPalette && int(cmd-cmdPalette) < len(paletteNames):
+		name := paletteNames[cmd-cmdPalette]
+		t.game.Post(func(g *Game) { g.SetPalette(name) })
+	}
+}
+
+// appendMenu adds an item to a menu
+func appendMenu(menu uintptr, flags uintptr, id int, text string) {
+	p, _ := windows.UTF16PtrFromString(text)
+	procAppendMenu.Call(menu, flags, uintptr(id), uintptr(unsafe.Pointer(p)))
+}
+
+// checkedIf returns the flag that shows a check mark beside a menu item
+func checkedIf(checked bool) uintptr {
+	if checked {
+		return MF_CHECKED
+	}
+	return 0
+}
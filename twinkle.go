@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Speed of the slow shimmer in flake brightness
+const (
+	twinkleMin = 0.02 // Slowest shimmer in radians per frame
+	twinkleMax = 0.08 // Fastest shimmer in radians per frame
+)
+
+// startTwinkle gives a new flake its own opacity and shimmer
+func (g *Game) startTwinkle(f *Snowflake, r *rand.Rand) {
+	f.alpha = 1 - r.Float64()*g.config.OpacityVariation
+	f.shimmer = r.Float64() * 2 * math.Pi
+	f.flicker = twinkleMin + r.Float64()*(twinkleMax-twinkleMin)
+}
+
+// twinkle moves the flake's shimmer on by a frame
+func (f *Snowflake) twinkle() {
+	f.shimmer = math.Mod(f.shimmer+f.flicker, 2*math.Pi)
+}
+
+// flakeAlpha returns how opaque the flake is right now, from 0 to 1,
+// relative to the configured opacity
+func (g *Game) flakeAlpha(f Snowflake) float64 {
+	return f.alpha * (1 - g.config.Twinkle*(0.5+0.5*math.Sin(f.shimmer)))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for the virtual desktop COM API
+const (
+	COINIT_APARTMENTTHREADED = 0x2
+	CLSCTX_ALL               = 0x17
+)
+
+var (
+	clsidVirtualDesktopManager = windows.GUID{
+		Data1: 0xAA509086, Data2: 0x5CA9, Data3: 0x4C25,
+		Data4: [8]byte{0x8F, 0x95, 0x58, 0x9D, 0x3C, 0x07, 0xB4, 0x8A},
+	}
+	iidVirtualDesktopManager = windows.GUID{
+		Data1: 0xA5CD92FF, Data2: 0x29BE, Data3: 0x454C,
+		Data4: [8]byte{0x8D, 0x04, 0xD8, 0x28, 0x79, 0xFB, 0x3F, 0x1B},
+	}
+)
+
+// virtualDesktopManager mirrors the IVirtualDesktopManager COM interface
+type virtualDesktopManager struct {
+	vtbl *struct {
+		QueryInterface                  uintptr
+		AddRef                          uintptr
+		Release                         uintptr
+		IsWindowOnCurrentVirtualDesktop uintptr
+		GetWindowDesktopId              uintptr
+		MoveWindowToDesktop             uintptr
+	}
+}
+
+// The manager, created on the message window's thread and only used there
+var desktopManager *virtualDesktopManager
+
+// followDesktop is the SetWinEventHook callback. Switching virtual desktops
+// brings a window on the new desktop to the foreground, so move the snow
+// window to whichever desktop that window is on.
+var followDesktop = windows.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, time uintptr) uintptr {
+	own := FindGameWindow()
+	if own == 0 || hwnd == 0 || hwnd == own {
+		return 0
+	}
+
+	// Attached to the wallpaper the window is on every desktop already
+	if parent, _, _ := procGetParent.Call(own); parent != 0 {
+		return 0
+	}
+
+	var current int32
+	if hr, _, _ := syscall.SyscallN(desktopManager.vtbl.IsWindowOnCurrentVirtualDesktop,
+		uintptr(unsafe.Pointer(desktopManager)), own, uintptr(unsafe.Pointer(&current))); hr != 0 || current != 0 {
+		return 0
+	}
+
+	// The desktop and taskbar belong to every virtual desktop and have no ID of their own
+	var desktop windows.GUID
+	if hr, _, _ := syscall.SyscallN(desktopManager.vtbl.GetWindowDesktopId,
+		uintptr(unsafe.Pointer(desktopManager)), hwnd, uintptr(unsafe.Pointer(&desktop))); hr != 0 || desktop == (windows.GUID{}) {
+		return 0
+	}
+	syscall.SyscallN(desktopManager.vtbl.MoveWindowToDesktop,
+		uintptr(unsafe.Pointer(desktopManager)), own, uintptr(unsafe.Pointer(&desktop)))
+	return 0
+})
+
+// WatchVirtualDesktops keeps the snow window on the virtual desktop the
+// user is looking at. Call it from the message window's setup function,
+// whose message loop delivers the events.
+func WatchVirtualDesktops() {
+	if hr, _, _ := procCoInitializeEx.Call(0, COINIT_APARTMENTTHREADED); int32(hr) < 0 {
+		log.Printf("Could not watch virtual desktops: CoInitializeEx failed with %#x", hr)
+		return
+	}
+
+	var manager *virtualDesktopManager
+	if hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidVirtualDesktopManager)), 0, CLSCTX_ALL,
+		uintptr(unsafe.Pointer(&iidVirtualDesktopManager)),
+		uintptr(unsafe.Pointer(&manager)),
+	); int32(hr) < 0 {
+		log.Printf("Could not watch virtual desktops: no virtual desktop manager (%#x)", hr)
+		return
+	}
+	desktopManager = manager
+
+	if hook, _, err := procSetWinEventHook.Call(EVENT_SYSTEM_FOREGROUND, EVENT_SYSTEM_FOREGROUND,
+		0, followDesktop, 0, 0, WINEVENT_OUTOFCONTEXT); hook == 0 {
+		log.Println("Could not watch virtual desktops:", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Constants for attaching to the desktop wallpaper layer
+const (
+	WM_SPAWN_WORKER   = 0x052C // Undocumented: asks Progman to create the WorkerW behind the icons
+	SMTO_NORMAL       = 0x0000
+	SM_XVIRTUALSCREEN = 76
+	SM_YVIRTUALSCREEN = 77
+	SWP_NOZORDER      = 0x0004
+)
+
+// The WorkerW window found by the last enumeration
+var workerW uintptr
+
+// findWorkerW is the EnumWindows callback. The WorkerW we want is the
+// sibling that follows the window hosting the desktop icons (SHELLDLL_DefView).
+var findWorkerW = windows.NewCallback(func(hwnd, lParam uintptr) uintptr {
+	defView, _ := windows.UTF16PtrFromString("SHELLDLL_DefView")
+	workerClass, _ := windows.UTF16PtrFromString("WorkerW")
+
+	if shell, _, _ := procFindWindowEx.Call(hwnd, 0, uintptr(unsafe.Pointer(defView)), 0); shell != 0 {
+		workerW, _, _ = procFindWindowEx.Call(0, hwnd, uintptr(unsafe.Pointer(workerClass)), 0)
+		return 0 // Stop enumerating
+	}
+	return 1
+})
+
+// AttachToWallpaper reparents the window into the desktop's WorkerW so it is
+// drawn behind the desktop icons like a real wallpaper, keeping it where it
+// currently is on screen
+func AttachToWallpaper(hwnd uintptr) error {
+	var r rect
+	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+
+	progmanClass, _ := windows.UTF16PtrFromString("Progman")
+	progman, _, _ := procFindWindow.Call(uintptr(unsafe.Pointer(progmanClass)), 0)
+	if progman == 0 {
+		return errors.New("desktop window (Progman) not found")
+	}
+
+	// Ask Explorer to split the desktop into the icon layer and a WorkerW behind it
+	var result uintptr
+	procSendMessageTimeout.Call(progman, WM_SPAWN_WORKER, 0xD, 0x1, SMTO_NORMAL, 1000, uintptr(unsafe.Pointer(&result)))
+
+	workerW = 0
+	procEnumWindows.Call(findWorkerW, 0)
+	if workerW == 0 {
+		// Newer Windows 11 builds keep the WorkerW as a child of Progman
+		workerClass, _ := windows.UTF16PtrFromString("WorkerW")
+		workerW, _, _ = procFindWindowEx.Call(progman, 0, uintptr(unsafe.Pointer(workerClass)), 0)
+	}
+	if workerW == 0 {
+		return errors.New("desktop WorkerW window not found")
+	}
+
+	if prev, _, err := procSetParent.Call(hwnd, workerW); prev == 0 && err != windows.ERROR_SUCCESS {
+		return err
+	}
+
+	placeWindow(hwnd, r)
+	return nil
+}
+
+// placeWindow moves the window to r, given in screen coordinates. Once the
+// window is attached to the wallpaper its position is relative to WorkerW,
+// which spans the whole virtual screen, so it is converted to that.
+func placeWindow(hwnd uintptr, r rect) {
+	var left, top uintptr
+	if parent, _, _ := procGetParent.Call(hwnd); parent != 0 {
+		left, _, _ = procGetSystemMetrics.Call(SM_XVIRTUALSCREEN)
+		top, _, _ = procGetSystemMetrics.Call(SM_YVIRTUALSCREEN)
+	}
+	procSetWindowPos.Call(hwnd, 0,
+		uintptr(r.left-int32(left)), uintptr(r.top-int32(top)),
+		uintptr(r.right-r.left), uintptr(r.bottom-r.top),
+		SWP_NOZORDER|SWP_NOACTIVATE|SWP_SHOWWINDOW)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long to wait for a burst of file events to settle before reloading
+const reloadDelay = 250 * time.Millisecond
+
+// WatchConfig calls reload whenever the config file at path changes.
+// The containing directory is watched rather than the file itself because
+// most editors save by replacing the file, which would drop a file watch.
+func WatchConfig(path string, reload func()) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Println("Could not create config directory:", err)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("Could not watch config file:", err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		log.Println("Could not watch config directory:", err)
+		return
+	}
+
+	// Debounce events so a single save only triggers one reload
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == filepath.Clean(path) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("Config watcher error:", err)
+		case <-timer.C:
+			reload()
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Weather lets the snow come and go in a long cycle, like a passing storm,
+// instead of falling at the same rate forever
+type Weather struct {
+	Cycle    bool    `toml:"cycle" json:"cycle"`       // Move through the stages below in turn
+	Clear    float64 `toml:"clear" json:"clear"`       // Minutes of clear sky with no snow
+	Light    float64 `toml:"light" json:"light"`       // Minutes of light snow
+	Heavy    float64 `toml:"heavy" json:"heavy"`       // Minutes of snow at the configured strength
+	Blizzard float64 `toml:"blizzard" json:"blizzard"` // Minutes of blizzard, 0 to skip it
+	Clearing float64 `toml:"clearing" json:"clearing"` // Minutes of the last flakes drifting down
+}
+
+// Validate checks that no stage has a negative length and that the cycle can move on
+func (w *Weather) Validate() error {
+	total := 0.0
+	for _, minutes := range w.dwell() {
+		if minutes < 0 {
+			return fmt.Errorf("weather stages must not be negative, got %g", minutes)
+		}
+		total += minutes
+	}
+	if w.Cycle && total == 0 {
+		return fmt.Errorf("weather cycle needs at least one stage longer than 0 minutes")
+	}
+	return nil
+}
+
+// Stages of the weather cycle, in order
+type weatherStage int
+
+const (
+	stageClear weatherStage = iota
+	stageLight
+	stageHeavy
+	stageBlizzard
+	stageClearing
+	weatherStages
+)
+
+// Names of the stages, for the status line
+var stageNames = [weatherStages]string{"clear", "light snow", "heavy snow", "blizzard", "clearing"}
+
+// Share of the configured flakes falling during each stage
+var stageFlakes = [weatherStages]float64{0, 0.3, 1, 1, 0.15}
+
+// Seconds to change from one stage's amount of snow to the next
+const weatherRamp = 60.0
+
+// dwell returns the minutes spent in each stage
+func (w *Weather) dwell() [weatherStages]float64 {
+	return [weatherStages]float64{w.Clear, w.Light, w.Heavy, w.Blizzard, w.Clearing}
+}
+
+// weatherState is where the game is in the weather cycle
+type weatherState struct {
+	stage weatherStage
+	until time.Time // When the next stage starts, zero before the cycle has begun
+	level float64   // Share of the configured flakes falling now, easing toward the stage's share
+}
+
+// updateWeather moves on to the next stage when the current one is over
+// and eases the amount of snow toward the stage's
+func (g *Game) updateWeather() {
+	w := &g.weather
+	if !g.config.Weather.Cycle {
+		w.until = time.Time{}
+		g.easeWeather(1)
+		return
+	}
+
+	now := time.Now()
+	if w.until.IsZero() {
+		// Start off with the snow as configured
+		w.stage = stageHeavy
+		w.until = now.Add(minutes(g.config.Weather.Heavy))
+	}
+	dwell := g.config.Weather.dwell()
+	for now.After(w.until) {
+		w.stage = (w.stage + 1) % weatherStages
+		w.until = w.until.Add(minutes(dwell[w.stage]))
+		if w.until.Before(now) {
+			continue
+		}
+		if w.stage == stageBlizzard {
+			g.blowBlizzard(w.until)
+		}
+	}
+	g.easeWeather(stageFlakes[w.stage])
+}
+
+// easeWeather moves the amount of snow a step toward target
+func (g *Game) easeWeather(target float64) {
+	w := &g.weather
+	if w.level == target {
+		return
+	}
+	step := 1 / (weatherRamp * float64(g.stepRate()))
+	if target > w.level {
+		w.level = min(w.level+step, target)
+	} else {
+		w.level = max(w.level-step, target)
+	}
+	g.resizeFlakes()
+}
+
+// weatherStatus describes the weather cycle for the status line
+func (g *Game) weatherStatus() string {
+	if !g.config.Weather.Cycle {
+		return ""
+	}
+	return fmt.Sprintf(", weather %s for %.0f more minutes",
+		stageNames[g.weather.stage], time.Until(g.weather.until).Minutes())
+}
+
+// minutes converts a number of minutes from the config to a duration
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// Windows API procedures shared across the app
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	shell32  = windows.NewLazySystemDLL("shell32.dll")
+	wtsapi32 = windows.NewLazySystemDLL("wtsapi32.dll")
+	ntdll    = windows.NewLazySystemDLL("ntdll.dll")
+	ole32    = windows.NewLazySystemDLL("ole32.dll")
+	dwmapi   = windows.NewLazySystemDLL("dwmapi.dll")
+	winmm    = windows.NewLazySystemDLL("winmm.dll")
+	gdi32    = windows.NewLazySystemDLL("gdi32.dll")
+
+	procAppendMenu            = user32.NewProc("AppendMenuW")
+	procCreatePopupMenu       = user32.NewProc("CreatePopupMenu")
+	procCreateWindowEx        = user32.NewProc("CreateWindowExW")
+	procDefWindowProc         = user32.NewProc("DefWindowProcW")
+	procDestroyMenu           = user32.NewProc("DestroyMenu")
+	procDispatchMessage       = user32.NewProc("DispatchMessageW")
+	procEnumDisplayMonitors   = user32.NewProc("EnumDisplayMonitors")
+	procEnumWindows           = user32.NewProc("EnumWindows")
+	procFindWindow            = user32.NewProc("FindWindowW")
+	procFindWindowEx          = user32.NewProc("FindWindowExW")
+	procGetClassName          = user32.NewProc("GetClassNameW")
+	procGetCursorPos          = user32.NewProc("GetCursorPos")
+	procGetForegroundWindow   = user32.NewProc("GetForegroundWindow")
+	procGetParent             = user32.NewProc("GetParent")
+	procGetWindow             = user32.NewProc("GetWindow")
+	procGetSystemMetrics      = user32.NewProc("GetSystemMetrics")
+	procGetMonitorInfo        = user32.NewProc("GetMonitorInfoW")
+	procGetWindowLongPtr      = user32.NewProc("GetWindowLongPtrW")
+	procGetWindowRect         = user32.NewProc("GetWindowRect")
+	procGetMessage            = user32.NewProc("GetMessageW")
+	procIsWindow              = user32.NewProc("IsWindow")
+	procIsWindowVisible       = user32.NewProc("IsWindowVisible")
+	procIsIconic              = user32.NewProc("IsIconic")
+	procLoadIcon              = user32.NewProc("LoadIconW")
+	procMonitorFromWindow     = user32.NewProc("MonitorFromWindow")
+	procPostMessage           = user32.NewProc("PostMessageW")
+	procQueryDisplayConfig    = user32.NewProc("QueryDisplayConfig")
+	procRegisterClassEx       = user32.NewProc("RegisterClassExW")
+	procRegisterHotKey        = user32.NewProc("RegisterHotKey")
+	procRegisterWindowMessage = user32.NewProc("RegisterWindowMessageW")
+	procSendMessageTimeout    = user32.NewProc("SendMessageTimeoutW")
+	procSetForegroundWindow   = user32.NewProc("SetForegroundWindow")
+	procSetParent             = user32.NewProc("SetParent")
+	procSetWindowLongPtr      = user32.NewProc("SetWindowLongPtrW")
+	procSetWindowPos          = user32.NewProc("SetWindowPos")
+	procSetWinEventHook       = user32.NewProc("SetWinEventHook")
+	procShowWindow            = user32.NewProc("ShowWindow")
+	procSystemParametersInfo  = user32.NewProc("SystemParametersInfoW")
+	procTrackPopupMenu        = user32.NewProc("TrackPopupMenu")
+	procTranslateMessage      = user32.NewProc("TranslateMessage")
+	procFreeConsole           = kernel32.NewProc("FreeConsole")
+	procGetConsoleProcessList = kernel32.NewProc("GetConsoleProcessList")
+	procGetModuleHandle       = kernel32.NewProc("GetModuleHandleW")
+	procGetSystemPowerStatus  = kernel32.NewProc("GetSystemPowerStatus")
+	procShellNotifyIcon       = shell32.NewProc("Shell_NotifyIconW")
+	procSHAppBarMessage       = shell32.NewProc("SHAppBarMessage")
+
+	procGetDisplayConfigBufferSizes      = user32.NewProc("GetDisplayConfigBufferSizes")
+	procGetWindowThreadProcessId         = user32.NewProc("GetWindowThreadProcessId")
+	procRegisterPowerSettingNotification = user32.NewProc("RegisterPowerSettingNotification")
+	procSetWindowCompositionAttribute    = user32.NewProc("SetWindowCompositionAttribute")
+	procSHQueryUserNotificationState     = shell32.NewProc("SHQueryUserNotificationState")
+	procWTSRegisterSessionNotification   = wtsapi32.NewProc("WTSRegisterSessionNotification")
+	procNtQueryWnfStateData              = ntdll.NewProc("NtQueryWnfStateData")
+	procCoInitializeEx                   = ole32.NewProc("CoInitializeEx")
+	procCoCreateInstance                 = ole32.NewProc("CoCreateInstance")
+	procDwmGetWindowAttribute            = dwmapi.NewProc("DwmGetWindowAttribute")
+	procPlaySound                        = winmm.NewProc("PlaySoundW")
+	procAddFontMemResourceEx             = gdi32.NewProc("AddFontMemResourceEx")
+	procCreateCompatibleDC               = gdi32.NewProc("CreateCompatibleDC")
+	procCreateDIBSection                 = gdi32.NewProc("CreateDIBSection")
+	procCreateFont                       = gdi32.NewProc("CreateFontW")
+	procDeleteDC                         = gdi32.NewProc("DeleteDC")
+	procDeleteObject                     = gdi32.NewProc("DeleteObject")
+	procGetGlyphIndices                  = gdi32.NewProc("GetGlyphIndicesW")
+	procGetTextExtentPoint32             = gdi32.NewProc("GetTextExtentPoint32W")
+	procSelectObject                     = gdi32.NewProc("SelectObject")
+	procSetBkColor                       = gdi32.NewProc("SetBkColor")
+	procSetTextColor                     = gdi32.NewProc("SetTextColor")
+	procTextOut                          = gdi32.NewProc("TextOutW")
+)
+
+// Window messages
+const (
+	WM_HOTKEY = 0x0312
+)
+
+// msg mirrors the Windows MSG structure
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// rect mirrors the Windows RECT structure
+type rect struct {
+	left, top, right, bottom int32
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Shape of the gusts rippling through the wind
+const (
+	gustDrift    = 0.004 // How fast the gust pattern changes, in noise units per frame
+	gustVertical = 0.3   // Strength of updrafts and downdrafts relative to sideways gusts
+	gustLayer    = 57.3  // Offset into the noise for the vertical gusts, so they differ from the sideways ones
+)
+
+// Noise is seeded Perlin gradient noise in three dimensions
+type Noise struct {
+	perm [512]uint8
+}
+
+// NewNoise shuffles a permutation table from r
+func NewNoise(r *rand.Rand) *Noise {
+	n := &Noise{}
+	for i, p := range r.Perm(256) {
+		n.perm[i] = uint8(p)
+		n.perm[i+256] = uint8(p)
+	}
+	return n
+}
+
+// fade is Perlin's smoothstep curve, flat at both ends
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// lerp interpolates between a and b
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad picks one of twelve gradient directions from the hash and
+// returns its dot product with the offset
+func grad(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// At returns the noise at a point, roughly between -1 and 1
+func (n *Noise) At(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &n.perm
+	a := int(p[xi]) + yi
+	aa, ab := int(p[a])+zi, int(p[a+1])+zi
+	b := int(p[xi+1]) + yi
+	ba, bb := int(p[b])+zi, int(p[b+1])+zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p[aa], x, y, z), grad(p[ba], x-1, y, z)),
+			lerp(u, grad(p[ab], x, y-1, z), grad(p[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(p[aa+1], x, y, z-1), grad(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad(p[ab+1], x, y-1, z-1), grad(p[bb+1], x-1, y-1, z-1))))
+}
+
+// windAt returns the wind at a point on screen as a depth layer feels it:
+// the overall wind plus gusts that vary across the screen and drift over
+// time, scaled and delayed for the layer
+func (g *Game) windAt(x, y float64, layer int) (wx, wy float64) {
+	scale, lag := g.config.windLayer(layer)
+	wind := g.layerWind[layer] * scale
+	if g.config.Gusts == 0 || g.config.GustSize <= 0 {
+		return wind, 0
+	}
+
+	// The gusts reach the layer as they were lag seconds ago
+	t := g.gustTime - lag*float64(g.stepRate())*gustDrift
+	nx, ny := x/g.config.GustSize, y/g.config.GustSize
+	strength := g.config.Gusts * max(g.config.Wind, math.Abs(g.baseWind())) * scale
+	wx = wind + strength*g.noise.At(nx, ny, t)
+	wy = strength * gustVertical * g.noise.At(nx+gustLayer, ny, t)
+	return wx, wy
+}
+
+// Depth layers the snow is split into by flake size, from the back to the front
+const windLayers = 3
+
+// depthLayer returns which depth layer a flake is in, taking small flakes
+// to be far away and big ones close by
+func (c *Config) depthLayer(f *Snowflake) int {
+	if c.SizeMax <= c.SizeMin {
+		return windLayers / 2
+	}
+	t := (f.size - c.SizeMin) / (c.SizeMax - c.SizeMin)
+	return min(max(int(t*windLayers), 0), windLayers-1)
+}
+
+// windLayer returns how strongly a depth layer feels the wind and how many
+// seconds it lags behind it
+func (c *Config) windLayer(layer int) (float64, float64) {
+	switch layer {
+	case 0:
+		return c.WindBack, c.WindLagBack
+	case windLayers - 1:
+		return c.WindFront, c.WindLagFront
+	}
+	return c.WindMiddle, c.WindLagMiddle
+}
+
+// updateLayerWind lets the wind each depth layer feels catch up with the
+// overall wind, the distant layers slowest
+func (g *Game) updateLayerWind() {
+	wind := g.baseWind()
+	for i := range g.layerWind {
+		_, lag := g.config.windLayer(i)
+		frames := lag * float64(g.stepRate())
+		if frames <= 1 {
+			g.layerWind[i] = wind
+		} else {
+			g.layerWind[i] += (wind - g.layerWind[i]) / frames
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"image/color"
 	"log"
 	"math/rand"
-	"syscall"
+	"os"
+	"strings"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -24,122 +28,548 @@ const (
 	GWL_EXSTYLE      = -20
 	WS_EX_LAYERED    = 0x80000
 	WS_EX_NOACTIVATE = 0x08000000
+	WS_EX_TOOLWINDOW = 0x00000080
+	WS_EX_APPWINDOW  = 0x00040000
+	SW_HIDE          = 0
+	SW_SHOWNA        = 8
+	GW_HWNDPREV      = 3
 )
 
 const (
-	screenWidth   = 1920 // Default, will be set to actual screen size
-	screenHeight  = 1080 // Default, will be set to actual screen size
-	numSnowflakes = 300
+	screenWidth  = 1920 // Default, will be set to actual screen size
+	screenHeight = 1080 // Default, will be set to actual screen size
 )
 
+// Reasons the simulation can be paused; it only runs while none apply
+type pauseReason uint
+
+const (
+	pausedByUser pauseReason = 1 << iota
+	pausedBySchedule
+	pausedByFullscreen
+	pausedByBattery
+	pausedBySession
+	pausedByScreensaver
+	pausedByFocusAssist
+	pausedByPresentation
+	pausedByReducedMotion
+)
+
+// Pause reasons that also release the snowflakes instead of freezing them
+const suspendReasons = pausedBySchedule
+
+// Ticks per second while paused, enough to stay responsive to commands
+const pausedTPS = 5
+
 // Snowflake represents a single snow particle
 type Snowflake struct {
 	x, y      float64
+	px, py    float64 // Where the flake was before the last physics step
 	size      float64
-	speed     float64
-	drift     float64
+	speed     float64 // Terminal speed, where air drag balances the flake's weight
+	vx, vy    float64 // Velocity in pixels per frame
+	drift     float64 // Sideways speed from turbulence
+	phase     float64 // Point in the flake's sideways sway
 	windSpeed float64
+	angle     float64 // Rotation of the sprite in radians
+	spin      float64 // Radians the sprite turns each frame in still air
+	design    int     // Which sprite in the atlas the flake is drawn with
+	scale     float64 // Times its own size a custom image is drawn
+	alpha     float64 // Opacity of this flake relative to the configured opacity
+	shimmer   float64 // Point in the flake's slow twinkle
+	flicker   float64 // Radians the twinkle moves on each frame
+	tint      float64 // Where in the color palette the flake picks its color from
+	clumped   bool    // Grown by sticking to other flakes in the air
 }
 
 // Game implements ebiten.Game interface
 type Game struct {
+	config         Config
+	rng            *rand.Rand // Source of all randomness, seeded from the config
+	configPath     string     // Where settings are saved, empty if unknown
 	snowflakes     []Snowflake
 	screenWidth    int
 	screenHeight   int
-	wind           float64 // Current wind strength
-	windTarget     float64 // Target wind strength
-	windChangeTime float64 // Time until next wind change
+	wind           float64             // Current wind strength
+	windTarget     float64             // Target wind strength
+	windChangeTime float64             // Time until next wind change
+	noise          *Noise              // Gusts varying the wind across the screen
+	layerWind      [windLayers]float64 // The overall wind as each depth layer feels it, lagging behind
+	gustTime       float64             // How far the gust pattern has drifted
+	flakeColor     color.NRGBA
+	batch          Batch            // Flakes waiting to be drawn, grouped by texture
+	soft           SoftFlakes       // Flakes waiting to be drawn by the snow shader
+	palette        []color.NRGBA    // Colors flakes are picked from, empty to draw them all in flakeColor
+	actions        chan func(*Game) // Changes from other goroutines, applied in Update
+	settings       SettingsOverlay
+	wizard         SetupWizard
+	nextSurprise   time.Time         // When surprise mode next randomizes the weather
+	paused         pauseReason       // Why the simulation is paused, zero if running
+	quit           bool              // Set to exit at the next Update
+	frozen         bool              // Whether the paused frame has been drawn
+	owed           float64           // Physics steps due but not yet run
+	nextFrame      time.Time         // When the frame cap next allows a frame to be drawn
+	quality        int               // Quality step the governor has dropped to under load
+	qualityAt      time.Time         // When the quality or the tick rate last changed
+	qualityTPS     int               // Tick rate the load is being measured against
+	busy           time.Duration     // Time spent in Update and Draw since the governor last looked
+	busyAt         time.Time         // When the governor last looked at the time spent
+	frameCost      float64           // Smoothed share of the time spent in Update and Draw
+	crowdedAt      time.Time         // When Update and Draw last left too little time spare
+	passthrough    bool              // Whether mouse clicks currently pass through the window
+	onBattery      bool              // Whether the machine is on battery or battery saver
+	remote         bool              // Whether the snow is shown over Remote Desktop or in a virtual machine
+	throttled      bool              // Whether fewer flakes are drawn at a lower frame rate to save power
+	focusAssist    bool              // Whether Focus Assist is on
+	focusAction    string            // What on_focus_assist did when Focus Assist turned on
+	beforeFocus    Config            // Settings before Focus Assist calmed the snow, to undo the calming
+	tray           *Tray             // Notification area icon, nil until it is added
+	lightTheme     bool              // Whether Windows is set to the light theme
+	highContrast   bool              // Whether a high contrast theme is on
+	solid          bool              // Whether to draw without transparency, for high contrast or with transparency effects off
+	reducedMotion  bool              // Whether Windows animations are turned off
+	piles          map[uintptr]*Pile // Snow lying on the taskbar and other edges, by window handle
+	eaves          map[uintptr]*Eave // Icicles hanging from monitor and window tops, by surface
+	stood          map[uintptr]rect  // Where each window was at the last poll, so only moved ones leave footprints
+	frost          float64           // How far frost has crept in from the corners, from 0 to 1
+	particles      Pool              // Sparks, mist and other short-lived specks with lives of their own
+	debris         Pool              // Clumps, bounces and bits of ice falling with the snow until they land
+	saved          savedPiles        // Snow saved by the last run, waiting for its surfaces to turn up
+	crystals       []*ebiten.Image   // Snowflake shapes generated for this run
+	cursor         Cursor            // The mouse pointer, which stirs up the snow
+	blizzard       Blizzard          // A storm on top of the configured weather
+	weather        weatherState      // Where the snow is in the weather cycle
+	grid           Grid              // Flakes and piles by position, for finding those near each other
+	clumpTick      int               // Frames counted toward the next check for clumping
+	customSprites  []customSprite    // The user's own flake images or glyphs, nil unless their flake style is on
+	customKey      string            // Settings the custom images or glyphs were made from
+	sky            Starfield         // Stars behind the snow
+	fireworks      Fireworks         // Rockets and bursts over the snow
+	fog            Fog               // Mist drifting under or over the snow
+	auroraTime     float64           // Seconds the northern lights have been moving
+	lightning      Lightning         // A strike flashing during a storm
+	sleigh         Sleigh            // Santa flying over at Christmas
+	plow           Plow              // A snow plow clearing the ground
+	moon           Moon              // The moon and clouds behind the snow
+	dayChecked     time.Time         // Minute the flakes were last recolored for the time of day
 }
 
 // Initialize creates all the snowflakes
 func (g *Game) Initialize() {
-	// Get the primary monitor size
-	g.screenWidth, g.screenHeight = ebiten.ScreenSizeInFullscreen()
+	// Cover every monitor, or just the one the window is on
+	g.screenWidth, g.screenHeight = g.screenSize()
 
 	// Initialize wind
 	g.wind = 0
 	g.windTarget = 0
 	g.windChangeTime = 0
 
+	g.updateColor()
+	g.actions = make(chan func(*Game), 16)
+	g.weather.level = 1
+	g.piles = map[uintptr]*Pile{}
+	g.eaves = map[uintptr]*Eave{}
+	g.updateGround()
+
 	// Create snowflakes
-	g.snowflakes = make([]Snowflake, numSnowflakes)
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	g.snowflakes = make([]Snowflake, g.config.Flakes)
+	seed := int64(g.config.Seed)
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	g.rng = rand.New(rand.NewSource(seed))
+	g.crystals = GenerateCrystals(g.rng)
+	g.noise = NewNoise(g.rng)
+	g.updateCustomSprites()
 
 	for i := range g.snowflakes {
-		g.snowflakes[i] = Snowflake{
-			x:     r.Float64() * float64(g.screenWidth),
-			y:     r.Float64() * float64(g.screenHeight),
-			size:  1.0 + r.Float64()*3.0,
-			speed: 6.0 + r.Float64()*10.0, // Min: 6.0, Max: 16.0
-			drift: 0,
+		g.snowflakes[i] = g.newFlake(g.rng)
+	}
+}
+
+// newFlake creates a snowflake at a random position using the current config
+func (g *Game) newFlake(r *rand.Rand) Snowflake {
+	w, h := g.fallSize()
+	f := Snowflake{
+		x:    r.Float64() * w,
+		y:    r.Float64() * h,
+		size: g.config.flakeSize(r),
+		tint: r.Float64(),
+	}
+	f.px, f.py = f.x, f.y
+	f.speed = g.config.terminalSpeed(f.size, r)
+	f.vy = f.speed
+	spinFlake(&f, r)
+	g.pickSprite(&f, r)
+	startWobble(&f, r)
+	g.startTwinkle(&f, r)
+	return f
+}
+
+// resizeFlakes adds or removes flakes to match the configured count,
+// or drops them all while the simulation is suspended
+func (g *Game) resizeFlakes() {
+	if g.paused&suspendReasons != 0 {
+		g.snowflakes = nil
+		return
+	}
+
+	if count := g.flakeCount(); count < len(g.snowflakes) {
+		g.snowflakes = g.snowflakes[:count]
+	} else {
+		for len(g.snowflakes) < count {
+			g.snowflakes = append(g.snowflakes, g.newFlake(g.rng))
 		}
 	}
 }
 
+// melting returns how much of the flake is left as it melts over the last
+// melt_distance pixels above the bottom of the screen, from 1 down to 0
+func (g *Game) melting(f Snowflake) float64 {
+	if g.config.MeltDistance <= 0 || !g.effect().melts {
+		return 1
+	}
+	_, h := g.fallSize()
+	return min(1, (h-f.y)/g.config.MeltDistance)
+}
+
+// updateColor recomputes the flake color from the color and opacity settings
+func (g *Game) updateColor() {
+	cfg := g.displayConfig(g.config)
+	c, _ := ParseColor(cfg.Color)
+	g.flakeColor = color.NRGBA{c.R, c.G, c.B, uint8(cfg.Opacity * 255)}
+	g.updatePalette(cfg)
+
+	switch {
+	case g.highContrast:
+		overlay = contrastPalette
+	case g.lightTheme && g.config.FollowTheme:
+		overlay = lightPalette
+	default:
+		overlay = darkPalette
+	}
+	if g.solid {
+		overlay.panel.A = 255
+	}
+}
+
+// SetPreset switches to the named preset at runtime
+func (g *Game) SetPreset(name string) error {
+	cfg := g.config
+	if err := cfg.ApplyPreset(name); err != nil {
+		return err
+	}
+	g.ApplyConfig(cfg)
+	return nil
+}
+
+// Adjust changes the settings live, saving them if hotkey changes should persist
+func (g *Game) Adjust(change func(*Config)) {
+	cfg := g.config
+	change(&cfg)
+	if err := cfg.Validate(); err != nil {
+		log.Println("Ignoring adjustment:", err)
+		return
+	}
+	g.ApplyConfig(cfg)
+
+	if cfg.HotkeysPersist {
+		if err := SaveSettings(g.configPath, cfg); err != nil {
+			log.Println("Could not save settings:", err)
+		}
+	}
+}
+
+// overlayOpen reports whether a panel that takes mouse input is showing
+func (g *Game) overlayOpen() bool {
+	return g.settings.open || g.wizard.open
+}
+
+// Call runs fn on the game goroutine and waits for it to finish.
+// It must not be called from the game goroutine itself.
+func (g *Game) Call(fn func(*Game)) {
+	done := make(chan struct{})
+	g.Post(func(g *Game) {
+		fn(g)
+		close(done)
+	})
+	<-done
+}
+
+// Pause stops the simulation for the given reason
+func (g *Game) Pause(reason pauseReason) {
+	if g.paused&reason == reason {
+		return
+	}
+	suspending := reason&suspendReasons != 0 && g.paused&suspendReasons == 0
+	g.paused |= reason
+	if suspending {
+		g.releaseGraphics()
+	}
+	g.resizeFlakes()
+	ebiten.SetTPS(g.tps())
+}
+
+// Resume clears the given pause reason; the simulation restarts once none are left
+func (g *Game) Resume(reason pauseReason) {
+	if g.paused&reason == 0 {
+		return
+	}
+	suspended := g.paused&suspendReasons != 0
+	g.paused &^= reason
+	if suspended && g.paused&suspendReasons == 0 {
+		g.restoreGraphics()
+	}
+	g.resizeFlakes()
+	ebiten.SetTPS(g.tps())
+}
+
+// Post queues a change to be applied on the game goroutine during the next Update.
+// It is safe to call from any goroutine.
+func (g *Game) Post(action func(*Game)) {
+	g.actions <- action
+}
+
+// ApplyConfig switches to new settings without restarting the simulation.
+// Flakes are only added or removed to reach the new count; the rest keep falling.
+func (g *Game) ApplyConfig(cfg Config) {
+	if cfg.Autostart != g.config.Autostart || cfg.Service != g.config.Service {
+		if err := SetAutostart(cfg.Autostart, cfg.Service); err != nil {
+			log.Println("Could not change autostart:", err)
+		}
+	}
+
+	if was, is := g.displayConfig(g.config), g.displayConfig(cfg); was.Backdrop != is.Backdrop || was.BackdropTint != is.BackdropTint {
+		ApplyBackdrop(FindGameWindow(), is)
+	}
+
+	g.config = cfg
+	g.updateColor()
+	g.updateCustomSprites()
+	g.applyThrottling()
+	g.applyMotion()
+	g.updateGround()
+
+	// Pick a new wind target within the new limits straight away
+	g.windChangeTime = 0
+}
+
 // Update updates the game state (implementing ebiten.Game)
 func (g *Game) Update() error {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	defer g.timeFrame(time.Now())
+
+	// Apply any pending changes from other goroutines
+	for len(g.actions) > 0 {
+		action := <-g.actions
+		action(g)
+	}
+
+	// Closing the window shuts down the same way as the other ways out
+	if g.quit || ebiten.IsWindowBeingClosed() {
+		g.saveState()
+		return ebiten.Termination
+	}
+
+	// Suspend outside of the scheduled hours
+	if g.config.Schedule.Active(time.Now()) {
+		g.Resume(pausedBySchedule)
+	} else {
+		g.Pause(pausedBySchedule)
+	}
+
+	g.wizard.Update(g)
+	g.settings.Update(g)
+
+	// Let clicks through to the desktop unless a panel needs them
+	if passthrough := g.config.ClickThrough && !g.overlayOpen(); passthrough != g.passthrough {
+		ebiten.SetWindowMousePassthrough(passthrough)
+		g.passthrough = passthrough
+	}
+	if g.paused != 0 {
+		return nil
+	}
+
+	g.updateQuality()
+
+	// Run as many physics steps as this tick's share of a second needs, so
+	// the snow keeps the same pace at any tick rate
+	g.owed += float64(g.stepRate()) / float64(g.tps())
+	for ; g.owed >= 1; g.owed-- {
+		g.step()
+	}
+	return nil
+}
+
+// step moves everything on by one physics step
+func (g *Game) step() {
+	r := g.rng
+
+	// Randomize the weather now and then in surprise mode
+	if g.config.Surprise && time.Now().After(g.nextSurprise) {
+		g.surprise(r)
+	}
+	g.updateWeather()
+	g.updateDaylight()
+	g.updateBlizzard(r)
+	g.updateLightning(r)
+	g.updateStars(r)
+	g.updateMoon(r)
+	g.updateFireworks(r)
+	g.updateFog(r)
+	g.updateSleigh(r)
+	g.updatePlow(r)
+	g.particles.Update(g)
 
 	// Update wind
 	g.windChangeTime -= 1.0
 	if g.windChangeTime <= 0 {
 		// Set new wind target
-		g.windTarget = (r.Float64()*2 - 1.0) * 0.8 // Range: -0.8 to 0.8
-		g.windChangeTime = 60 + r.Float64()*120    // Change every 60-180 frames
+		g.windTarget = g.config.WindBias + (r.Float64()*2-1.0)*g.config.Wind // Range: -Wind to Wind around the bias
+		g.windChangeTime = g.config.WindChangeMin + r.Float64()*(g.config.WindChangeMax-g.config.WindChangeMin)
 	}
 
 	// Gradually adjust wind toward target (subtle change)
 	g.wind = g.wind*0.99 + g.windTarget*0.01
+	g.gustTime += gustDrift
+	g.updateLayerWind()
+	g.auroraTime += 1 / float64(g.stepRate())
+	g.trackCursor()
+	if c := g.cursor; c.known {
+		g.tread(c.x-footprintWidth/2, c.y, footprintWidth)
+	}
+	g.updateCursorPile()
+	g.updateBreath(r)
+	g.updateIcicles(r)
+	g.updateFrost()
+
+	// Stir up the falling flakes near the mouse pointer
+	g.index()
+	if c := g.fallCursor(); c.known && g.effect().move == nil {
+		g.grid.Near(c.x, c.y, g.config.CursorRadius, func(i int) {
+			g.stir(&g.snowflakes[i], c)
+		})
+	}
 
 	// Update snowflakes
+	_, bottom := g.fallSize()
 	for i := range g.snowflakes {
-		// Apply wind effect - larger flakes affected less by wind
-		windEffect := g.wind / g.snowflakes[i].size
-		g.snowflakes[i].x += windEffect
-
-		// Apply velocity
-		g.snowflakes[i].y += g.snowflakes[i].speed
-
-		// Reset if out of bounds
-		if g.snowflakes[i].y > float64(g.screenHeight) {
-			g.snowflakes[i].y = 0
-			g.snowflakes[i].x = r.Float64() * float64(g.screenWidth)
+		g.snowflakes[i].px, g.snowflakes[i].py = g.snowflakes[i].x, g.snowflakes[i].y
+
+		// Effects with their own way of moving don't fall at all
+		if move := g.effect().move; move != nil {
+			move(g, &g.snowflakes[i], r)
+			g.snowflakes[i].twinkle()
+			g.wrapAround(&g.snowflakes[i])
+			continue
 		}
 
-		// Wrap around left/right edges if needed
-		if g.snowflakes[i].x < 0 {
-			g.snowflakes[i].x = float64(g.screenWidth)
-		} else if g.snowflakes[i].x > float64(g.screenWidth) {
-			g.snowflakes[i].x = 0
+		// Let gravity and the wind where the flake is work on it - heavier
+		// flakes respond to the wind more slowly
+		wx, wy := g.windAt(g.snowflakes[i].x, g.snowflakes[i].y, g.config.depthLayer(&g.snowflakes[i]))
+		g.fall(&g.snowflakes[i], wx, wy)
+		dx, dy := g.wobble(&g.snowflakes[i], r)
+		g.snowflakes[i].x += g.snowflakes[i].vx + dx
+		g.snowflakes[i].turn(wx)
+		g.snowflakes[i].twinkle()
+
+		// Apply velocity, never letting an updraft carry the flake upwards
+		prevY := g.snowflakes[i].y
+		g.snowflakes[i].y += max(g.snowflakes[i].vy+dy, 0)
+
+		// Reset if landed on a pile or out of bounds
+		landed := g.land(&g.snowflakes[i], prevY)
+		if landed || g.snowflakes[i].y > bottom {
+			if impact := g.effect().impact; impact != nil {
+				if !landed {
+					g.snowflakes[i].y = bottom
+				}
+				impact(g, &g.snowflakes[i])
+			}
+			g.respawn(&g.snowflakes[i], r)
 		}
+
+		g.wrapAround(&g.snowflakes[i])
 	}
+	g.debris.Update(g)
+	g.clumpFlakes(r)
+	g.settlePiles()
+	g.dripPiles(r)
+	g.avalanches()
+}
 
-	return nil
+// wrapAround moves a flake that has left one side of the screen to the other
+func (g *Game) wrapAround(f *Snowflake) {
+	w, _ := g.fallSize()
+	if f.x < 0 {
+		f.x = w
+	} else if f.x > w {
+		f.x = 0
+	}
 }
 
 // Draw draws the game screen (implementing ebiten.Game)
 func (g *Game) Draw(screen *ebiten.Image) {
-	// Clear the screen with transparent black
-	screen.Fill(color.RGBA{0, 0, 0, 255})
+	defer g.timeFrame(time.Now())
 
-	// Draw snowflakes
+	// The screen is not cleared between frames, so while paused the
+	// last frame can stay up without redrawing it
+	if g.paused != 0 && !g.overlayOpen() && g.frozen {
+		return
+	}
+	if !g.frameDue() {
+		return
+	}
+	g.frozen = g.paused != 0
+
+	g.clearScreen(screen)
+	g.drawStars(screen)
+	g.drawMoon(screen)
+	g.drawAurora(screen)
+	g.drawLightning(screen)
+	g.drawFireworks(screen)
+	g.drawSleigh(screen)
+	g.particles.Draw(g, screen, false)
+	g.drawFog(screen, fogUnder)
+
+	// Draw snowflakes, turned to fall the way gravity pulls
+	layer := g.fallTarget(screen)
 	for _, flake := range g.snowflakes {
-		size := int(flake.size)
-		x, y := int(flake.x), int(flake.y)
-
-		if size <= 1 {
-			screen.Set(x, y, color.White)
-		} else {
-			// Draw larger snowflakes as circles
-			for dx := -size / 2; dx <= size/2; dx++ {
-				for dy := -size / 2; dy <= size/2; dy++ {
-					if dx*dx+dy*dy <= size*size/4 {
-						screen.Set(x+dx, y+dy, color.White)
-					}
-				}
+		g.tween(&flake)
+
+		// Each flake has its own opacity, and melts away near the bottom of the screen
+		c := g.flakeTint(flake)
+		c.A = uint8(float64(c.A) * g.flakeAlpha(flake))
+		if left := g.melting(flake); left < 1 {
+			if left <= 0 {
+				continue
 			}
+			flake.size *= left
+			c.A = uint8(float64(c.A) * left)
 		}
+		g.drawStreak(layer, flake, float64(c.A)/float64(max(g.flakeColor.A, 1)))
+		g.drawFlake(layer, flake, c)
 	}
+	g.debris.Draw(g, layer, false)
+	g.batch.Flush()
+	g.flushSoft(layer)
+	g.finishFall(screen, layer)
+	g.particles.Draw(g, screen, true)
+	g.drawFog(screen, fogOver)
+	g.drawGroundFog(screen)
+
+	for _, p := range g.piles {
+		p.Draw(screen, g.flakeColor)
+	}
+	if p := g.cursor.pile; p != nil {
+		p.Draw(screen, g.flakeColor)
+	}
+	g.drawPlow(screen)
+	g.drawIcicles(screen)
+	g.drawFrost(screen)
+	g.drawHUD(screen)
+
+	g.settings.Draw(screen, g.config)
+	g.wizard.Draw(screen, g)
 }
 
 // Layout returns the screen dimensions (implementing ebiten.Game)
@@ -147,40 +577,77 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return g.screenWidth, g.screenHeight
 }
 
-// SetWindowToBottom sets the window to be behind all applications but in front of the desktop
-func SetWindowToBottom() {
-	// Get the window handle using Windows API
-	user32 := windows.NewLazySystemDLL("user32.dll")
-	procFindWindow := user32.NewProc("FindWindowW")
-	procSetWindowPos := user32.NewProc("SetWindowPos")
-	procGetForegroundWindow := user32.NewProc("GetForegroundWindow")
-
-	// Convert window title to UTF16
-	title, _ := syscall.UTF16PtrFromString("Snow Wallpaper")
-
-	// Find the window by title
-	hwnd, _, _ := procFindWindow.Call(
-		0,
-		uintptr(unsafe.Pointer(title)),
-	)
+// Ebiten's window class, from the GLFW library it is built on
+const gameWindowClass = "GLFW30"
 
-	if hwnd == 0 {
-		// Try with class name instead
-		className, _ := syscall.UTF16PtrFromString("Ebiten")
-		hwnd, _, _ = procFindWindow.Call(
-			uintptr(unsafe.Pointer(className)),
-			0,
-		)
+// The snow window once found, guarded by gameWindowMu
+var (
+	gameWindow   uintptr
+	gameWindowMu sync.Mutex
+)
 
-		if hwnd == 0 {
-			log.Println("Could not find window handle, will retry later")
-			return
+// findOwnWindow is the EnumWindows callback. Ebiten doesn't expose the
+// window handle, so look for the top-level GLFW window belonging to this
+// process; other applications' windows can never match, whatever their title.
+var findOwnWindow = windows.NewCallback(func(hwnd, lParam uintptr) uintptr {
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if pid == windows.GetCurrentProcessId() && windowClass(hwnd) == gameWindowClass {
+		if visible, _, _ := procIsWindowVisible.Call(hwnd); visible != 0 {
+			gameWindow = hwnd
+			return 0 // Stop enumerating
 		}
 	}
+	return 1
+})
+
+// FindGameWindow returns the handle of the snow window, or 0 if it doesn't exist yet.
+// The handle is remembered, since the window is no longer top-level once it
+// is attached to the wallpaper.
+func FindGameWindow() uintptr {
+	gameWindowMu.Lock()
+	defer gameWindowMu.Unlock()
+
+	if gameWindow != 0 {
+		if ok, _, _ := procIsWindow.Call(gameWindow); ok != 0 {
+			return gameWindow
+		}
+		gameWindow = 0
+	}
+	procEnumWindows.Call(findOwnWindow, 0)
+	return gameWindow
+}
+
+// SetWindowToBottom sets the window to be behind all applications but in front of the desktop
+func SetWindowToBottom() {
+	hwnd := FindGameWindow()
+	if hwnd == 0 {
+		log.Println("Could not find window handle, will retry later")
+		return
+	}
 
 	// Get the foreground window
 	fgHwnd, _, _ := procGetForegroundWindow.Call()
 
+	// After Show Desktop (Win+D) the desktop is in front of the other
+	// windows, so sit just above it instead of disappearing behind it.
+	// A click on the desktop leaves it at the bottom, and then so do we.
+	if fgHwnd != 0 && desktopClasses[windowClass(fgHwnd)] {
+		procShowWindow.Call(hwnd, SW_SHOWNOACTIVATE) // Undo any minimize
+		above, _, _ := procGetWindow.Call(fgHwnd, GW_HWNDPREV)
+		if above == hwnd {
+			return
+		}
+		// Going below the window just above the desktop puts us right on top of it
+		procSetWindowPos.Call(
+			hwnd,
+			above, // HWND_TOP when nothing is above the desktop
+			0, 0, 0, 0,
+			uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE|SWP_SHOWWINDOW),
+		)
+		return
+	}
+
 	// Set the window position to be at the bottom of the Z-order
 	// and make sure it's not activated
 	procSetWindowPos.Call(
@@ -201,26 +668,202 @@ func SetWindowToBottom() {
 	}
 }
 
+// HideFromTaskSwitcher keeps the window out of Alt-Tab, Win+Tab and the
+// taskbar by making it a tool window
+func HideFromTaskSwitcher(hwnd uintptr) {
+	// GWL_EXSTYLE is negative, so pass it through a variable to convert it
+	index := GWL_EXSTYLE
+	style, _, _ := procGetWindowLongPtr.Call(hwnd, uintptr(index))
+	style = style&^WS_EX_APPWINDOW | WS_EX_TOOLWINDOW
+	procSetWindowLongPtr.Call(hwnd, uintptr(index), style)
+
+	// The taskbar only notices the new style when the window is shown again
+	procShowWindow.Call(hwnd, SW_HIDE)
+	procShowWindow.Call(hwnd, SW_SHOWNA)
+}
+
 func main() {
+	// A config file beside the executable means portable mode
+	portable = HasPortableConfig()
+
+	// Dispatch subcommands; anything else starts the snow
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "run":
+			args = args[1:]
+		case "config":
+			configPath, err := ConfigPath()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := runConfigCommand(args[1:], configPath); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "service":
+			// With a command, talk to the supervisor; otherwise become it
+			if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+				reply, err := SendServiceCommand(args[1])
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(reply)
+				return
+			}
+			if err := RunService(args[1:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "pause", "resume", "status", "quit", "blizzard", "fireworks", "confetti", "sleigh", "plow":
+			// Talk to the running instance instead of starting another one
+			reply, err := SendCommand(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(reply)
+			return
+		default:
+			log.Fatalf("Unknown command %q (expected run, service, pause, resume, status, quit, blizzard, fireworks, confetti, sleigh, plow or config)", args[0])
+		}
+	}
+
+	flags := NewFlags()
+	flags.Parse(args)
+	portable = portable || flags.Portable
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		log.Println("Could not locate config directory:", err)
+	}
+
+	if flags.CheckConfig {
+		os.Exit(checkConfig(configPath, flags))
+	}
+
+	// Load settings; environment variables and then command-line flags
+	// take priority over the config file
+	cfg, err := ResolveConfig(configPath, flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Show the snow on the chosen monitor, if it is connected; spanning
+	// all monitors is done on the window once it exists
+	if monitors := ebiten.AppendMonitors(nil); !cfg.AllMonitors && cfg.Monitor < len(monitors) {
+		ebiten.SetMonitor(monitors[cfg.Monitor])
+	} else if !cfg.AllMonitors && cfg.Monitor != 0 {
+		log.Printf("Monitor %d not found, using the primary monitor", cfg.Monitor)
+	}
+
+	// Keep the login entry in step with the config, and pointing at
+	// this executable in case it has moved
+	if err := SetAutostart(cfg.Autostart, cfg.Service); err != nil {
+		log.Println("Could not change autostart:", err)
+	}
+
 	// Create game instance
-	game := &Game{}
+	game := &Game{config: cfg, configPath: configPath}
 	game.Initialize()
+	game.loadState()
+	game.remote = IsRemoteSession() || IsVirtualMachine()
+	game.applyThrottling()
+
+	// Walk through the basic choices the first time winsnow runs
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && !HasRegistrySettings() {
+		game.wizard.Open(game)
+	}
+
+	// Listen for commands from later invocations; failing to do so
+	// means another instance already has the snow running
+	if err := ServeIPC(game); err != nil {
+		log.Fatal("winsnow is already running (", err, ")")
+	}
+
+	go ListenHotkeys(game, cfg.Hotkeys)
+	go WatchFullscreen(game)
+	go WatchScreensaver(game)
+	go WatchFocusAssist(game)
+	go WatchPresentation(game)
+	go WatchSurfaces(game)
+	go WatchSignals(game)
+
+	// Closed once the game has shut down and cleaned up
+	stopped := make(chan struct{})
+
+	// Put the controls in the notification area. The tray is handed back
+	// over a channel as it's added on the message window's thread.
+	trays := make(chan *Tray, 1)
+	StartMessageWindow(func(w *MessageWindow) {
+		tray := AddTray(w, game)
+		trays <- tray
+		game.Post(func(g *Game) { g.tray = tray })
+		WatchDisplays(w, game)
+		WatchPower(w, game)
+		WatchSession(w, game)
+		WatchEndSession(w, game, stopped)
+		WatchShowDesktop()
+		WatchVirtualDesktops()
+		WatchTheme(w, game)
+		WatchAccessibility(w, game)
+	})
+
+	// Apply config file edits live
+	if configPath != "" {
+		go WatchConfig(configPath, func() {
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				log.Println("Ignoring config change:", err)
+				return
+			}
+			if err := cfg.ApplyOverrides(flags); err != nil {
+				log.Println("Ignoring config change:", err)
+				return
+			}
+			game.Post(func(g *Game) { g.ApplyConfig(cfg) })
+		})
+	}
 
 	// Configure Ebiten
 	ebiten.SetWindowTitle("Snow Wallpaper")
+	ebiten.SetWindowClosingHandled(true)
 	ebiten.SetWindowSize(game.screenWidth, game.screenHeight)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
-	ebiten.SetFullscreen(true)
+	// As a wallpaper the window is parented to the desktop, which rules out fullscreen,
+	// and fullscreen only covers one whole monitor
+	ebiten.SetFullscreen(!cfg.Wallpaper && !cfg.AllMonitors && !cfg.WorkArea)
 	ebiten.SetWindowDecorated(false) // No window decorations (title bar, etc.)
 	ebiten.SetWindowPosition(0, 0)   // Position window at top-left corner
 	ebiten.SetRunnableOnUnfocused(true)
 	ebiten.SetScreenTransparent(true)
+	ebiten.SetScreenClearedEveryFrame(false) // Draw fills the whole screen itself
 
 	// Run window positioning in background repeatedly
 	go func() {
 		// Give the window time to be created first
 		time.Sleep(500 * time.Millisecond)
 
+		if hwnd := FindGameWindow(); hwnd != 0 {
+			HideFromTaskSwitcher(hwnd)
+			game.Post(func(g *Game) { ApplyBackdrop(hwnd, g.displayConfig(g.config)) })
+			if cfg.AllMonitors || cfg.WorkArea {
+				FitWindow(hwnd, cfg)
+				game.Post(func(g *Game) { g.Relayout() })
+			}
+		}
+
+		// Behind the desktop icons the window stays put on its own
+		if cfg.Wallpaper {
+			err := errors.New("window not found")
+			if hwnd := FindGameWindow(); hwnd != 0 {
+				err = AttachToWallpaper(hwnd)
+			}
+			if err == nil {
+				return
+			}
+			log.Println("Could not attach to the wallpaper, keeping the window at the bottom instead:", err)
+		}
+
 		// Try positioning the window repeatedly
 		ticker := time.NewTicker(1 * time.Second)
 		for range ticker.C {
@@ -228,7 +871,14 @@ func main() {
 		}
 	}()
 
-	if err := ebiten.RunGame(game); err != nil {
+	err = ebiten.RunGame(game)
+	select {
+	case tray := <-trays:
+		tray.Remove()
+	default:
+	}
+	close(stopped)
+	if err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Layout of the setup wizard
+const (
+	wizardX        = 40
+	wizardY        = 40
+	wizardWidth    = 420
+	buttonHeight   = 24
+	buttonSpacing  = 8
+	rowHeight      = buttonHeight + buttonSpacing
+	sectionSpacing = 12
+)
+
+// wizardButton is a clickable choice in the setup wizard
+type wizardButton struct {
+	label      string
+	x, y, w, h int
+	selected   func(*SetupWizard, *Game) bool
+	click      func(*SetupWizard, *Game)
+}
+
+// wizardLabel is a section heading in the setup wizard
+type wizardLabel struct {
+	text string
+	x, y int
+}
+
+// SetupWizard is shown on the first launch to pick the monitor,
+// intensity preset and whether to start at login
+type SetupWizard struct {
+	open      bool
+	autostart bool
+	buttons   []wizardButton
+	labels    []wizardLabel
+	height    int
+}
+
+// Open shows the wizard with a button for each choice
+func (w *SetupWizard) Open(g *Game) {
+	w.open = true
+	w.buttons = nil
+	w.labels = nil
+	y := wizardY + settingsPadding + 36
+
+	// Monitors
+	monitors := ebiten.AppendMonitors(nil)
+	y = w.addLabel("Monitor", y)
+	for i, m := range monitors {
+		w.addButton(i, y, fmt.Sprintf("%d: %s", i, m.Name()),
+			func(w *SetupWizard, g *Game) bool { return g.config.Monitor == i },
+			func(w *SetupWizard, g *Game) {
+				cfg := g.config
+				cfg.Monitor = i
+				ebiten.SetMonitor(monitors[i])
+				g.ApplyConfig(cfg)
+			})
+	}
+	y += rowHeight * ((len(monitors) + 2) / 3)
+
+	// Presets
+	y = w.addLabel("Intensity", y+sectionSpacing)
+	for i, name := range []string{"calm", "flurry", "blizzard"} {
+		w.addButton(i, y, name,
+			func(w *SetupWizard, g *Game) bool { return g.config.Preset == name },
+			func(w *SetupWizard, g *Game) { g.SetPreset(name) })
+	}
+	y += rowHeight
+
+	// Autostart
+	y = w.addLabel("Startup", y+sectionSpacing)
+	w.addButton(0, y, "start at login",
+		func(w *SetupWizard, g *Game) bool { return w.autostart },
+		func(w *SetupWizard, g *Game) { w.autostart = !w.autostart })
+	y += rowHeight + sectionSpacing
+
+	w.addButton(2, y, "Done",
+		func(w *SetupWizard, g *Game) bool { return false },
+		func(w *SetupWizard, g *Game) { w.finish(g) })
+
+	w.height = y + buttonHeight + settingsPadding - wizardY
+}
+
+// addLabel adds a section heading at y and returns where its buttons go
+func (w *SetupWizard) addLabel(text string, y int) int {
+	w.labels = append(w.labels, wizardLabel{text, wizardX + settingsPadding, y})
+	return y + 18
+}
+
+// addButton adds a button in column col of the row starting at y
+func (w *SetupWizard) addButton(col, y int, label string, selected func(*SetupWizard, *Game) bool, click func(*SetupWizard, *Game)) {
+	width := (wizardWidth - settingsPadding*2 - buttonSpacing*2) / 3
+	w.buttons = append(w.buttons, wizardButton{
+		label:    label,
+		x:        wizardX + settingsPadding + (col%3)*(width+buttonSpacing),
+		y:        y + (col/3)*rowHeight,
+		w:        width,
+		h:        buttonHeight,
+		selected: selected,
+		click:    click,
+	})
+}
+
+// finish saves the choices and closes the wizard
+func (w *SetupWizard) finish(g *Game) {
+	w.open = false
+
+	cfg := g.config
+	cfg.Autostart = w.autostart
+	g.ApplyConfig(cfg)
+
+	if err := SaveSettings(g.configPath, g.config); err != nil {
+		log.Println("Could not save settings:", err)
+	}
+}
+
+// Update handles clicks on the wizard's buttons
+func (w *SetupWizard) Update(g *Game) {
+	if !w.open || !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+
+	mx, my := ebiten.CursorPosition()
+	for _, b := range w.buttons {
+		if mx >= b.x && mx < b.x+b.w && my >= b.y && my < b.y+b.h {
+			b.click(w, g)
+			return
+		}
+	}
+}
+
+// Draw renders the wizard if it is open
+func (w *SetupWizard) Draw(screen *ebiten.Image, g *Game) {
+	if !w.open {
+		return
+	}
+
+	vector.DrawFilledRect(screen, wizardX, wizardY, wizardWidth, float32(w.height), overlay.panel, false)
+	ebitenutil.DebugPrintAt(screen, "Welcome to winsnow! Pick a monitor, an intensity and", wizardX+settingsPadding, wizardY+settingsPadding-4)
+	ebitenutil.DebugPrintAt(screen, "whether to start at login, then click Done.", wizardX+settingsPadding, wizardY+settingsPadding+10)
+
+	for _, l := range w.labels {
+		ebitenutil.DebugPrintAt(screen, l.text, l.x, l.y)
+	}
+	for _, b := range w.buttons {
+		c := overlay.button
+		if b.selected(w, g) {
+			c = overlay.buttonChosen
+		}
+		vector.DrawFilledRect(screen, float32(b.x), float32(b.y), float32(b.w), float32(b.h), c, false)
+		ebitenutil.DebugPrintAt(screen, b.label, b.x+6, b.y+4)
+	}
+}
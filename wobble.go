@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Motion of a one pixel flake as it sways and tumbles; bigger flakes
+// sway less far and more slowly
+const (
+	wobbleAmplitude = 3.0  // Pixels either side of its path a flake sways
+	wobbleFrequency = 0.06 // Radians of sway per frame
+	turbulenceDamp  = 0.9  // Share of the tumbling speed kept from one frame to the next
+)
+
+// startWobble sets the flake swaying from a random point in its swing
+func startWobble(f *Snowflake, r *rand.Rand) {
+	f.phase = r.Float64() * 2 * math.Pi
+	f.drift = 0
+}
+
+// wobble returns how far the flake moves sideways and down this frame as
+// it sways and is knocked about by turbulence. Effects that spiral also
+// bob up and down in time with the sway.
+func (g *Game) wobble(f *Snowflake, r *rand.Rand) (dx, dy float64) {
+	e := g.effect()
+	freq := wobbleFrequency / math.Sqrt(f.size)
+	f.phase = math.Mod(f.phase+freq, 2*math.Pi)
+	amplitude := g.config.Wobble * e.sway * wobbleAmplitude / f.size * freq
+	dx = amplitude * math.Cos(f.phase)
+	dy = amplitude * e.spiral * math.Sin(f.phase)
+
+	if g.config.Turbulence > 0 {
+		f.drift = f.drift*turbulenceDamp + r.NormFloat64()*g.config.Turbulence/f.size*(1-turbulenceDamp)
+	}
+	return dx + f.drift, dy
+}